@@ -0,0 +1,176 @@
+// Package api serves the dependency graph as read-only JSON, for tools that
+// want the data without the page:
+//
+//	GET /api/graph                                     every entity and edge
+//	GET /api/entities/{namespace}/{kind}/{name}        one entity, with its YAML
+//	GET /api/neighbors/{id}?depth=N&direction=upstream|downstream|both
+//	                                                   the entities within N edges
+//	GET /api/lint                                      problems found in the manifests
+//
+// Entity IDs are namespace/kind/name. Neighbors have their own prefix rather
+// than a suffix after the ID, which could not be told apart from an object
+// named "neighbors".
+// Every response carries an ETag; requests with a matching If-None-Match get
+// 304 Not Modified, so that clients can poll cheaply.
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+)
+
+// Source gives the API access to a graph that may change while it is served.
+type Source interface {
+	// ReadGraph calls f with the graph, which must not change until f
+	// returns.
+	ReadGraph(f func(graph *dependency.Graph))
+}
+
+// maxDepth bounds the depth of neighbor queries.
+const maxDepth = 100
+
+type handler struct {
+	source Source
+}
+
+// NewHandler returns the handler of the API, to be mounted at /api/.
+func NewHandler(source Source) http.Handler {
+	return &handler{source}
+}
+
+// statusError is an error answered with its status code.
+type statusError struct {
+	status  int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+func (h *handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		rw.Header().Set("Allow", "GET, HEAD")
+		writeError(rw, &statusError{http.StatusMethodNotAllowed, "method not allowed"})
+		return
+	}
+	var result interface{}
+	var err error
+	h.source.ReadGraph(func(graph *dependency.Graph) {
+		result, err = route(graph, r)
+	})
+	if err != nil {
+		writeError(rw, err)
+		return
+	}
+	var content []byte
+	content, err = json.Marshal(result)
+	if err != nil {
+		writeError(rw, fmt.Errorf("json: marshal: %s", err))
+		return
+	}
+	var sum = sha256.Sum256(content)
+	var etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+	rw.Header().Set("ETag", etag)
+	rw.Header().Set("Cache-Control", "no-cache")
+	if matchETag(r.Header.Get("If-None-Match"), etag) {
+		rw.WriteHeader(http.StatusNotModified)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	if r.Method == http.MethodHead {
+		return
+	}
+	rw.Write(content)
+}
+
+func route(graph *dependency.Graph, r *http.Request) (interface{}, error) {
+	var path = r.URL.Path
+	switch {
+	case path == "/api/graph":
+		return newGraph(graph), nil
+	case path == "/api/lint":
+		return newLint(graph), nil
+	case strings.HasPrefix(path, "/api/entities/"):
+		return entityDetails(graph, strings.TrimPrefix(path, "/api/entities/"))
+	case strings.HasPrefix(path, "/api/neighbors/"):
+		return neighbors(graph, strings.TrimPrefix(path, "/api/neighbors/"), r)
+	}
+	return nil, &statusError{http.StatusNotFound, fmt.Sprintf("%s not found", path)}
+}
+
+func lookup(graph *dependency.Graph, id string) (*dependency.Entity, error) {
+	var ref, err = dependency.ParseRef(id)
+	if err != nil {
+		return nil, &statusError{http.StatusBadRequest, err.Error()}
+	}
+	var e *dependency.Entity
+	var ok bool
+	e, ok = graph.Get(ref)
+	if !ok {
+		return nil, &statusError{http.StatusNotFound, fmt.Sprintf("entity %s not found", ref)}
+	}
+	return e, nil
+}
+
+func entityDetails(graph *dependency.Graph, id string) (interface{}, error) {
+	var e, err = lookup(graph, id)
+	if err != nil {
+		return nil, err
+	}
+	return newDetails(graph, e), nil
+}
+
+func neighbors(graph *dependency.Graph, id string, r *http.Request) (interface{}, error) {
+	var root, err = lookup(graph, id)
+	if err != nil {
+		return nil, err
+	}
+	var query = r.URL.Query()
+	var depth = 1
+	if query.Get("depth") != "" {
+		depth, err = strconv.Atoi(query.Get("depth"))
+		if err != nil || depth < 0 || depth > maxDepth {
+			return nil, &statusError{http.StatusBadRequest, fmt.Sprintf("depth must be a number between 0 and %d", maxDepth)}
+		}
+	}
+	var direction = dependency.DirectionBoth
+	if query.Get("direction") != "" {
+		direction, err = dependency.ParseDirection(query.Get("direction"))
+		if err != nil {
+			return nil, &statusError{http.StatusBadRequest, "direction must be upstream (or incoming), downstream (or outgoing) or both"}
+		}
+	}
+	return newNeighborhood(graph, root, depth, direction), nil
+}
+
+func writeError(rw http.ResponseWriter, err error) {
+	var status = http.StatusInternalServerError
+	var serr, ok = err.(*statusError)
+	if ok {
+		status = serr.status
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	json.NewEncoder(rw).Encode(map[string]string{"error": err.Error()})
+}
+
+// matchETag tells whether an If-None-Match header lists etag.
+func matchETag(header string, etag string) bool {
+	var candidate string
+	for _, candidate = range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
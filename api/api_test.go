@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+)
+
+type graphSource struct {
+	graph *dependency.Graph
+}
+
+func (s graphSource) ReadGraph(f func(graph *dependency.Graph)) {
+	f(s.graph)
+}
+
+func get(t *testing.T, h http.Handler, path string, result interface{}) int {
+	t.Helper()
+	var rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, path, nil))
+	if rw.Code == http.StatusOK {
+		var err = json.Unmarshal(rw.Body.Bytes(), result)
+		if err != nil {
+			t.Fatalf("GET %s: %s\n%s", path, err, rw.Body.String())
+		}
+	}
+	return rw.Code
+}
+
+func TestRoutes(t *testing.T) {
+	var dir = t.TempDir()
+	var err = ioutil.WriteFile(filepath.Join(dir, "all.yaml"), []byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: neighbors
+  namespace: shop
+spec:
+  selector:
+    app: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+  labels:
+    app: web
+---
+kind: Values
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var graph *dependency.Graph
+	graph, err = dependency.BuildGraph(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var h = NewHandler(graphSource{graph})
+
+	var details map[string]interface{}
+	var status = get(t, h, "/api/entities/shop/Service/neighbors", &details)
+	if status != http.StatusOK || details["id"] != "shop/Service/neighbors" {
+		t.Errorf("got %d %v for the Service named neighbors", status, details)
+	}
+
+	var around neighborhood
+	status = get(t, h, "/api/neighbors/shop/Service/neighbors?direction=downstream", &around)
+	if status != http.StatusOK || len(around.Nodes) != 2 || len(around.Edges) != 1 {
+		t.Errorf("got %d %+v for the neighbors of the Service", status, around)
+	}
+	status = get(t, h, "/api/neighbors/shop/Service/neighbors?depth=-1", &around)
+	if status != http.StatusBadRequest {
+		t.Errorf("got %d for a negative depth, want %d", status, http.StatusBadRequest)
+	}
+
+	var problems map[string][]map[string]interface{}
+	status = get(t, h, "/api/lint", &problems)
+	if status != http.StatusOK || len(problems["diagnostics"]) != 1 {
+		t.Fatalf("got %d %v for the lint", status, problems)
+	}
+	var d = problems["diagnostics"][0]
+	if d["severity"] != "warning" || d["file"] != filepath.Join(dir, "all.yaml") || d["line"] != float64(18) || d["message"] == nil {
+		t.Errorf("got diagnostic %v", d)
+	}
+}
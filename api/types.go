@@ -0,0 +1,130 @@
+package api
+
+import (
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+)
+
+type entity struct {
+	ID          string            `json:"id"`
+	Kind        string            `json:"kind"`
+	Namespace   string            `json:"namespace"`
+	Name        string            `json:"name"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	File        string            `json:"file,omitempty"`
+	Line        int               `json:"line,omitempty"`
+}
+
+type edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+}
+
+type graph struct {
+	Nodes []entity `json:"nodes"`
+	Edges []edge   `json:"edges"`
+}
+
+type details struct {
+	entity
+	YAML     string `json:"yaml,omitempty"`
+	Incoming []edge `json:"incoming"`
+	Outgoing []edge `json:"outgoing"`
+}
+
+type neighbor struct {
+	entity
+	// Distance is the number of edges between the neighbor and the root.
+	Distance int `json:"distance"`
+}
+
+type neighborhood struct {
+	Root  string `json:"root"`
+	Depth int    `json:"depth"`
+	// Direction is the direction the edges were followed in: upstream
+	// towards the entities referencing the root, downstream towards those
+	// it references, or both. The request may also spell them incoming and
+	// outgoing; no direction means both.
+	Direction string     `json:"direction"`
+	Nodes     []neighbor `json:"nodes"`
+	Edges     []edge     `json:"edges"`
+}
+
+type lint struct {
+	// Diagnostics are the problems found reading the manifests.
+	Diagnostics []dependency.Diagnostic `json:"diagnostics"`
+	// Problems are the problems of the objects themselves, such as
+	// references to undefined Services.
+	Problems []dependency.Diagnostic `json:"problems"`
+}
+
+func newEntity(e *dependency.Entity) entity {
+	var namespace = e.Metadata.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+	return entity{
+		ID:          e.ID,
+		Kind:        e.Kind,
+		Namespace:   namespace,
+		Name:        e.DisplayName(),
+		Labels:      e.Metadata.Labels,
+		Annotations: e.Metadata.Annotations,
+		File:        e.Source.File,
+		Line:        e.Source.Line,
+	}
+}
+
+func newEdges(edges []dependency.Edge) []edge {
+	var result = []edge{}
+	var obj dependency.Edge
+	for _, obj = range edges {
+		result = append(result, edge{obj.From, obj.To, string(obj.Kind)})
+	}
+	return result
+}
+
+func newGraph(g *dependency.Graph) graph {
+	var result = graph{Nodes: []entity{}, Edges: newEdges(g.Edges())}
+	var e *dependency.Entity
+	for _, e = range g.Nodes() {
+		result.Nodes = append(result.Nodes, newEntity(e))
+	}
+	return result
+}
+
+func newDetails(g *dependency.Graph, e *dependency.Entity) details {
+	return details{
+		entity:   newEntity(e),
+		YAML:     e.Raw,
+		Incoming: newEdges(g.EdgesOf(e.ID, dependency.DirectionIncoming)),
+		Outgoing: newEdges(g.EdgesOf(e.ID, dependency.DirectionOutgoing)),
+	}
+}
+
+// newNeighborhood lists the entities reached from root, up to depth edges
+// away, and the edges between them.
+func newNeighborhood(g *dependency.Graph, root *dependency.Entity, depth int, direction dependency.Direction) neighborhood {
+	var result = neighborhood{Root: root.ID, Depth: depth, Direction: direction.String(), Nodes: []neighbor{}, Edges: []edge{}}
+	var distance = map[string]int{}
+	var r dependency.Reached
+	for _, r = range g.Reach(root.Ref(), depth, direction) {
+		distance[r.Entity.ID] = r.Distance
+		result.Nodes = append(result.Nodes, neighbor{newEntity(r.Entity), r.Distance})
+	}
+	var obj dependency.Edge
+	for _, obj = range g.Edges() {
+		var fromOK, toOK bool
+		_, fromOK = distance[obj.From]
+		_, toOK = distance[obj.To]
+		if fromOK && toOK {
+			result.Edges = append(result.Edges, edge{obj.From, obj.To, string(obj.Kind)})
+		}
+	}
+	return result
+}
+
+func newLint(g *dependency.Graph) lint {
+	return lint{g.Diagnostics(), g.Lint()}
+}
@@ -0,0 +1,140 @@
+package dependency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// cacheVersion is stored in every cache entry; entries written with another
+// version are ignored. Bump it whenever the cached data changes shape.
+const cacheVersion = 7
+
+// cacheEntry is what the on-disk cache keeps for one manifest file: the
+// entities and diagnostics it produced, and the hash of the content they were
+// parsed from.
+type cacheEntry struct {
+	Version     int
+	Hash        string
+	Entities    []cachedEntity
+	Diagnostics []Diagnostic
+}
+
+type cachedEntity struct {
+	Entity *Entity
+	Inputs inputs
+}
+
+// fileCache stores parsed files in a directory, one JSON file per manifest
+// named after the hash of its absolute path.
+type fileCache struct {
+	dir string
+}
+
+func newFileCache(options *Options) *fileCache {
+	if options == nil || options.CacheDir == "" {
+		return nil
+	}
+	return &fileCache{options.CacheDir}
+}
+
+func (c *fileCache) entryPath(path string) string {
+	var abs string
+	var err error
+	abs, err = filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	var sum = sha256.Sum256([]byte(abs))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func contentHash(content []byte) string {
+	var sum = sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// get returns the cached result for path if it was parsed from content with
+// the given hash, with the IDs computed for a graph built from root. A nil
+// cache never has anything.
+func (c *fileCache) get(path string, root string, hash string) *fileResult {
+	if c == nil {
+		return nil
+	}
+	var data []byte
+	var err error
+	data, err = ioutil.ReadFile(c.entryPath(path))
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	err = json.Unmarshal(data, &entry)
+	if err != nil || entry.Version != cacheVersion || entry.Hash != hash {
+		return nil
+	}
+	var result = &fileResult{root: root, diagnostics: entry.Diagnostics}
+	var cached cachedEntity
+	for _, cached = range entry.Entities {
+		var e = cached.Entity
+		e.inputs = cached.Inputs
+		e.Source.File = path
+		e.location = relativeLocation(root, e.Source)
+		e.ID = entityID(e)
+		result.entities = append(result.entities, e)
+	}
+	var index int
+	for index = range result.diagnostics {
+		result.diagnostics[index].Source.File = path
+	}
+	return result
+}
+
+// put stores result as the parse of path. Failing to write the cache is not
+// an error: the file is simply parsed again next time.
+func (c *fileCache) put(path string, hash string, result *fileResult) {
+	if c == nil {
+		return
+	}
+	var entry = cacheEntry{
+		Version:     cacheVersion,
+		Hash:        hash,
+		Entities:    []cachedEntity{},
+		Diagnostics: result.diagnostics,
+	}
+	var e *Entity
+	for _, e = range result.entities {
+		entry.Entities = append(entry.Entities, cachedEntity{e, e.inputs})
+	}
+	var data []byte
+	var err error
+	data, err = json.Marshal(&entry)
+	if err != nil {
+		return
+	}
+	err = os.MkdirAll(c.dir, 0755)
+	if err != nil {
+		return
+	}
+	var file *os.File
+	file, err = ioutil.TempFile(c.dir, ".entry-")
+	if err != nil {
+		return
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Close()
+	} else {
+		file.Close()
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return
+	}
+	err = os.Rename(file.Name(), c.entryPath(path))
+	if err != nil {
+		os.Remove(file.Name())
+	}
+}
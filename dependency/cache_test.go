@@ -0,0 +1,98 @@
+package dependency
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestCachedLoadMatchesFreshLoad(t *testing.T) {
+	var dir = t.TempDir()
+	var options = &Options{CacheDir: t.TempDir()}
+	writeManifests(t, dir, map[string]string{
+		"web.yaml":           webService + "---\n" + webDeployment,
+		"db.yaml":            dbService,
+		"settings.yaml":      settingsConfigMap,
+		"copy/settings.yaml": settingsConfigMap,
+		"values.yaml":        "replicaCount: 2\n",
+	})
+	var want = describeGraph(buildGraph(t, dir, nil))
+	var got = describeGraph(buildGraph(t, dir, options))
+	if got != want {
+		t.Fatalf("first cached load differs from a fresh load\ngot:\n%s\nwant:\n%s", got, want)
+	}
+	got = describeGraph(buildGraph(t, dir, options))
+	if got != want {
+		t.Fatalf("load from the cache differs from a fresh load\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	// A changed file must be parsed again instead of taken from the cache.
+	writeManifests(t, dir, map[string]string{"db.yaml": "", "web.yaml": webDeployment + "  replicas: 3\n"})
+	want = describeGraph(buildGraph(t, dir, nil))
+	got = describeGraph(buildGraph(t, dir, options))
+	if got != want {
+		t.Fatalf("cached load after a change differs from a fresh load\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Reloading goes through the cache as well.
+	var g = buildGraph(t, dir, options)
+	writeManifests(t, dir, map[string]string{"db.yaml": dbService})
+	var err = g.Reload(filepath.Join(dir, "db.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, want = describeGraph(g), describeGraph(buildGraph(t, dir, nil))
+	if got != want {
+		t.Fatalf("cached reload differs from a fresh load\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCacheEntryValidation(t *testing.T) {
+	var dir = t.TempDir()
+	var cache = &fileCache{t.TempDir()}
+	var path = filepath.Join(dir, "db.yaml")
+	writeManifests(t, dir, map[string]string{"db.yaml": dbService})
+	var result = loadFile(path, dir, cache)
+	if len(result.entities) != 1 {
+		t.Fatalf("got %d entities, want 1", len(result.entities))
+	}
+	var hash = contentHash([]byte(dbService))
+	var cached = cache.get(path, dir, hash)
+	if cached == nil || len(cached.entities) != 1 || cached.entities[0].ID != EntityID("shop", "Service", "db") {
+		t.Fatalf("got %+v from the cache, want the db service", cached)
+	}
+	if cache.get(path, dir, contentHash([]byte(webService))) != nil {
+		t.Fatalf("entry returned for content with another hash")
+	}
+	if cache.get(filepath.Join(dir, "other.yaml"), dir, hash) != nil {
+		t.Fatalf("entry returned for another path")
+	}
+
+	// Entries written by another version of the cache are ignored.
+	var data, err = ioutil.ReadFile(cache.entryPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry cacheEntry
+	err = json.Unmarshal(data, &entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Version = cacheVersion - 1
+	data, err = json.Marshal(&entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(cache.entryPath(path), data, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cache.get(path, dir, hash) != nil {
+		t.Fatalf("entry of cache version %d returned", entry.Version)
+	}
+	result = loadFile(path, dir, cache)
+	if len(result.entities) != 1 || cache.get(path, dir, hash) == nil {
+		t.Fatalf("stale entry was not parsed again and replaced")
+	}
+}
@@ -2,31 +2,121 @@ package dependency
 
 import (
 	"fmt"
-	"io/ioutil"
-	"log"
-	"os"
-	"path/filepath"
-	"strings"
-
-	"github.com/gkawamoto/kube-second-mate/k8s"
-	yaml "gopkg.in/yaml.v2"
 )
 
-// Graph ?
+// Graph is the dependency graph of the Kubernetes objects found in a
+// directory of manifests. Its nodes are entities and its edges are the
+// references between them, such as an Ingress routing to a Service or a
+// Service selecting the pods of a Deployment.
+//
+// A Graph can be read from several goroutines at once, but Reload must not
+// run concurrently with any other method.
 type Graph struct {
-	entities      []*Entity
-	hash          map[string]*Entity
-	referenceHash map[string][]string
+	entities   []*Entity
+	hash       map[string]*Entity
+	outgoing   map[string][]Edge
+	incoming   map[string][]Edge
+	labels     labelIndex
+	options    *Options
+	duplicates []Source
+	// root is the absolute directory the graph was built from.
+	root string
+
+	loadDiagnostics []Diagnostic
+}
+
+// EdgeKind tells why one entity depends on another.
+type EdgeKind string
+
+const (
+	// EdgeKindIngressBackend goes from an Ingress to a Service it routes
+	// traffic to.
+	EdgeKindIngressBackend EdgeKind = "ingress-backend"
+	// EdgeKindServiceSelector goes from a Service to a workload whose labels
+	// match the Service selector.
+	EdgeKindServiceSelector EdgeKind = "service-selector"
+	// EdgeKindServiceReference goes from a workload to a Service listed in
+	// its kube.references.services annotation.
+	EdgeKindServiceReference EdgeKind = "service-reference"
+	// EdgeKindVirtualServiceRoute goes from an Istio VirtualService to a
+	// Service of the cluster it routes traffic to.
+	EdgeKindVirtualServiceRoute EdgeKind = "virtualservice-route"
+	// EdgeKindConfigReference goes from a workload to a ConfigMap or Secret
+	// its pods mount or read environment variables from.
+	EdgeKindConfigReference EdgeKind = "config-reference"
+	// EdgeKindVolumeClaim goes from a workload to a PersistentVolumeClaim
+	// its pods mount.
+	EdgeKindVolumeClaim EdgeKind = "volume-claim"
+)
+
+// Edge is a reference from one entity to another, identified by their IDs.
+type Edge struct {
+	From string
+	To   string
+	Kind EdgeKind
+}
+
+// Direction selects which edges Neighbors follows.
+type Direction int
+
+const (
+	// DirectionOutgoing follows edges from the entity to the entities it
+	// references, downstream in the traffic flow.
+	DirectionOutgoing Direction = 1 << iota
+	// DirectionIncoming follows edges from the entities that reference the
+	// entity, upstream in the traffic flow.
+	DirectionIncoming
+	// DirectionBoth follows edges in both directions.
+	DirectionBoth = DirectionOutgoing | DirectionIncoming
+)
+
+// String returns the name ParseDirection reads for the direction: downstream,
+// upstream or both.
+func (d Direction) String() string {
+	switch d {
+	case DirectionOutgoing:
+		return "downstream"
+	case DirectionIncoming:
+		return "upstream"
+	case DirectionBoth:
+		return "both"
+	}
+	return fmt.Sprintf("Direction(%d)", int(d))
+}
+
+// ParseDirection reads a direction written as downstream (or outgoing),
+// upstream (or incoming), or both.
+func ParseDirection(s string) (Direction, error) {
+	switch s {
+	case "downstream", "outgoing":
+		return DirectionOutgoing, nil
+	case "upstream", "incoming":
+		return DirectionIncoming, nil
+	case "both":
+		return DirectionBoth, nil
+	}
+	return 0, fmt.Errorf("invalid direction %q: expected upstream, downstream or both", s)
 }
 
-// BuildGraph ?
-func BuildGraph(target string) (*Graph, error) {
+// BuildGraph reads every .yaml and .yml file below target and builds the
+// graph of the objects they define. options may be nil.
+//
+// Files that cannot be read or parsed do not make BuildGraph fail: they are
+// reported by Diagnostics and the graph is built from the rest. An error is
+// only returned when target itself cannot be read.
+func BuildGraph(target string, options *Options) (*Graph, error) {
 	var result = Graph{
-		entities:      []*Entity{},
-		hash:          map[string]*Entity{},
-		referenceHash: map[string][]string{},
+		entities: []*Entity{},
+		hash:     map[string]*Entity{},
+		outgoing: map[string][]Edge{},
+		incoming: map[string][]Edge{},
+		options:  options,
 	}
 	var err error
+	result.root, err = targetRoot(target)
+	if err != nil {
+		return nil, err
+	}
 	err = result.retrieveEntities(target)
 	if err != nil {
 		return nil, err
@@ -38,300 +128,169 @@ func BuildGraph(target string) (*Graph, error) {
 	return &result, nil
 }
 
-// Entities ?
-func (g *Graph) Entities() []*Entity {
+// Nodes returns the entities of the graph: first the objects in the order
+// their manifests were read, then the placeholders created for references
+// to objects that no manifest defines. The slice must not be modified.
+func (g *Graph) Nodes() []*Entity {
 	return g.entities
 }
 
-// References ?
-func (g *Graph) References() map[int]int {
-	var result = map[int]int{}
-	var toRefs []string
-	var from, to string
-	for from, toRefs = range g.referenceHash {
-		for _, to = range toRefs {
-			result[g.hash[from].ID] = g.hash[to].ID
-		}
+// Entities returns the entities of the graph.
+//
+// Deprecated: use Nodes.
+func (g *Graph) Entities() []*Entity {
+	return g.Nodes()
+}
+
+// Edges returns every edge of the graph, grouped by the entity they start
+// from in the order of Nodes.
+func (g *Graph) Edges() []Edge {
+	var result = []Edge{}
+	var e *Entity
+	for _, e = range g.entities {
+		result = append(result, g.outgoing[e.ID]...)
 	}
 	return result
 }
 
-func (g *Graph) buildEdges() error {
-	var err error
-	var index int
-	var entity *Entity
-	for index, entity = range g.entities {
-		entity.ID = index
-		entity.uid = entityUID(entity)
-	}
-	for index, entity = range g.entities {
-		err = g.resolveDependencies(entity)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+// Get returns the entity identified by ref.
+func (g *Graph) Get(ref Ref) (*Entity, bool) {
+	var e, ok = g.hash[ref.String()]
+	return e, ok
 }
 
-func (g *Graph) resolveDependencies(entity *Entity) error {
-	var err error
-	//log.Println("resolveDependencies", entity.Kind, entity.Metadata.Name)
-	switch entity.Kind {
-	case "Ingress":
-		err = g.resolveIngressDependencies(entity)
-	case "Service":
-		err = g.resolveServiceDependencies(entity)
-	case "Deployment":
-		err = g.resolveDeploymentDependencies(entity)
-	case "DaemonSet":
-		err = g.resolveDaemonSetDependencies(entity)
-	}
-	return err
+// Entity returns the entity with the given ID, or nil if there is none.
+func (g *Graph) Entity(id string) *Entity {
+	return g.hash[id]
 }
 
-func (g *Graph) resolveIngressDependencies(entity *Entity) error {
-	var obj k8s.Ingress
-	var err = yaml.Unmarshal([]byte(entity.raw), &obj)
-	if err != nil {
-		return err
+// EdgesOf returns the edges starting or ending at the entity with the given
+// ID, as selected by direction.
+func (g *Graph) EdgesOf(id string, direction Direction) []Edge {
+	var result = []Edge{}
+	if direction&DirectionOutgoing != 0 {
+		result = append(result, g.outgoing[id]...)
 	}
-	var rule k8s.IngressRule
-	for _, rule = range obj.Spec.Rules {
-		var httpPath k8s.IngressRuleHTTPPath
-		for _, httpPath = range rule.HTTP.Paths {
-			var e *Entity
-			var ok bool
-			var uid = kindNameUID("Service", httpPath.Backend.ServiceName)
-			e, ok = g.hash[uid]
-			if !ok {
-				e = &Entity{}
-				e.uid = uid
-				e.ID = len(g.entities)
-				e.Kind = "UnknownService"
-				e.Metadata.Name = httpPath.Backend.ServiceName
-				g.addEntity(e)
-			}
-			g.makeReference(entity.uid, uid)
-		}
+	if direction&DirectionIncoming != 0 {
+		result = append(result, g.incoming[id]...)
 	}
-	return nil
-}
-
-func (g *Graph) makeReference(from, to string) {
-	g.referenceHash[from] = append(g.referenceHash[from], to)
-	//g.referenceHash[from] = commonslice.RemoveDuplicateString(g.referenceHash[from])
-	//log.Println(g.referenceHash[from])
+	return result
 }
 
-func (g *Graph) resolveServiceDependencies(entity *Entity) error {
-	var obj k8s.Service
-	var err = yaml.Unmarshal([]byte(entity.raw), &obj)
-	if err != nil {
-		return err
-	}
-	var key, value string
-	var e *Entity
-	for _, e = range g.entities {
-		if e.Kind != "DaemonSet" && e.Kind != "Deployment" {
+// Neighbors returns the entities directly connected to the entity identified
+// by ref, following the edges selected by direction. Each neighbor is listed
+// once, even when several edges lead to it.
+func (g *Graph) Neighbors(ref Ref, direction Direction) []*Entity {
+	var id = ref.String()
+	var result = []*Entity{}
+	var seen = map[string]bool{}
+	var edge Edge
+	for _, edge = range g.EdgesOf(id, direction) {
+		var other = edge.To
+		if other == id {
+			other = edge.From
+		}
+		if seen[other] {
 			continue
 		}
-		if e.Kind == "DaemonSet" {
-			var ds k8s.DaemonSet
-			err = yaml.Unmarshal([]byte(e.raw), &ds)
-			if err != nil {
-				return err
-			}
-			var found = true
-			for key, value = range obj.Spec.Selector {
-				var value2 string
-				var ok bool
-				value2, ok = ds.Metadata.Labels[key]
-				if !ok {
-					found = false
-					break
-				}
-				if value != value2 {
-					found = false
-					break
-				}
-			}
-			if found {
-				g.makeReference(entity.uid, e.uid)
-			}
-		} else if e.Kind == "Deployment" {
-			var ds k8s.Deployment
-			err = yaml.Unmarshal([]byte(e.raw), &ds)
-			if err != nil {
-				return err
-			}
-			var found = true
-			for key, value = range obj.Spec.Selector {
-				var value2 string
-				var ok bool
-				value2, ok = ds.Metadata.Labels[key]
-				if !ok {
-					found = false
-					break
-				}
-				if value != value2 {
-					found = false
-					break
-				}
-			}
-			if found {
-				g.makeReference(entity.uid, e.uid)
-			}
+		seen[other] = true
+		result = append(result, g.hash[other])
+	}
+	return result
+}
+
+func (g *Graph) buildEdges() error {
+	var entity *Entity
+	g.outgoing = map[string][]Edge{}
+	g.incoming = map[string][]Edge{}
+	g.labels = labelIndex{}
+	for _, entity = range g.entities {
+		if entity.Kind == "Deployment" || entity.Kind == "DaemonSet" {
+			g.labels.add(entity)
 		}
 	}
+	for _, entity = range g.entities {
+		g.resolveDependencies(entity)
+	}
 	return nil
 }
 
-func (g *Graph) resolveDaemonSetDependencies(entity *Entity) error {
-	var obj k8s.DaemonSet
-	var err = yaml.Unmarshal([]byte(entity.raw), &obj)
-	if err != nil {
-		return err
+func (g *Graph) resolveDependencies(entity *Entity) {
+	var b backend
+	for _, b = range entity.inputs.Backends {
+		g.referenceService(entity, entity.Metadata.Namespace, b.Service, EdgeKindIngressBackend)
 	}
-	var services string
-	var ok bool
-	services, ok = obj.Metadata.Annotations["kube.references.services"]
-	if !ok {
-		return nil
+	var name string
+	for _, name = range entity.inputs.References {
+		g.referenceService(entity, entity.Metadata.Namespace, name, EdgeKindServiceReference)
 	}
-	return g.resolveKubeReferencesAnnotationsDependencies(services, entity)
-}
-func (g *Graph) resolveKubeReferencesAnnotationsDependencies(services string, entity *Entity) error {
-	var service string
-	for _, service = range strings.Split(services, ",") {
-		log.Println(entity.Metadata.Name, service)
+	var ref Ref
+	for _, ref = range entity.inputs.Routes {
+		g.referenceService(entity, ref.Namespace, ref.Name, EdgeKindVirtualServiceRoute)
+	}
+	// ConfigMaps, Secrets and claims are often created outside of the
+	// manifests, so only those the manifests define are linked.
+	for _, ref = range entity.inputs.Uses {
+		var kind = EdgeKindConfigReference
+		if ref.Kind == "PersistentVolumeClaim" {
+			kind = EdgeKindVolumeClaim
+		}
+		var _, ok = g.Get(ref)
+		if ok {
+			g.makeReference(Edge{entity.ID, ref.String(), kind})
+		}
+	}
+	if entity.inputs.Selector != nil {
 		var e *Entity
-		var ok bool
-		var uid = kindNameUID("Service", service)
-		e, ok = g.hash[uid]
-		if !ok {
-			e = &Entity{}
-			e.uid = uid
-			e.ID = len(g.entities)
-			e.Kind = "UnknownService"
-			e.Metadata.Name = service
-			g.addEntity(e)
+		for _, e = range g.labels.match(entity.Metadata.Namespace, entity.inputs.Selector) {
+			g.makeReference(Edge{entity.ID, e.ID, EdgeKindServiceSelector})
 		}
-		g.makeReference(entity.uid, uid)
 	}
-	return nil
-}
-
-func (g *Graph) addEntity(e *Entity) {
-	g.entities = append(g.entities, e)
-	g.hash[e.uid] = e
 }
 
-func (g *Graph) resolveDeploymentDependencies(entity *Entity) error {
-	var obj k8s.Deployment
-	var err = yaml.Unmarshal([]byte(entity.raw), &obj)
-	if err != nil {
-		return err
-	}
-	var services string
+// referenceService makes entity reference the named Service of namespace,
+// adding an UnknownService placeholder when no manifest defines it.
+func (g *Graph) referenceService(entity *Entity, namespace string, name string, kind EdgeKind) {
 	var ok bool
-	services, ok = obj.Metadata.Annotations["kube.references.services"]
+	var id = EntityID(namespace, "Service", name)
+	_, ok = g.hash[id]
 	if !ok {
-		return nil
+		var e = &Entity{}
+		e.ID = id
+		e.Kind = KindUnknownService
+		e.Metadata.Name = name
+		e.Metadata.Namespace = namespace
+		g.addEntity(e)
 	}
-	return g.resolveKubeReferencesAnnotationsDependencies(services, entity)
-}
-
-func entityUID(entity *Entity) string {
-	return kindNameUID(entity.Kind, entity.Metadata.Name)
+	g.makeReference(Edge{entity.ID, id, kind})
 }
 
-func kindNameUID(kind, name string) string {
-	return fmt.Sprintf("%s/%s", kind, name)
-}
-
-// Entity ?
-type Entity struct {
-	ID       int
-	uid      string
-	Kind     string `yaml:"kind"`
-	Metadata struct {
-		Name string `yaml:"name"`
-	} `yaml:"metadata"`
-	raw string
-}
-
-// EntityReference ?
-type EntityReference struct {
-	from       int
-	to         int
-	stringFrom string
-	stringTo   string
-}
-
-func (g *Graph) retrieveEntities(target string) error {
-	var err = filepath.Walk(target, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+func (g *Graph) makeReference(edge Edge) {
+	var other Edge
+	for _, other = range g.outgoing[edge.From] {
+		if other == edge {
+			return
 		}
-		if info.IsDir() {
-			return nil
-		}
-		if !strings.HasSuffix(info.Name(), ".yaml") && !strings.HasSuffix(info.Name(), ".yml") {
-			return nil
-		}
-		var data []byte
-		data, err = ioutil.ReadFile(path)
-		if err != nil {
-			return fmt.Errorf("readfile: %s", err)
-		}
-		err = g.resolveEntities(data)
-		if err != nil {
-			return fmt.Errorf("resolveEntities: %s", err)
-		}
-		return nil
-	})
-	return err
+	}
+	g.outgoing[edge.From] = append(g.outgoing[edge.From], edge)
+	g.incoming[edge.To] = append(g.incoming[edge.To], edge)
 }
 
-func (g *Graph) resolveEntities(content []byte) error {
-	var data map[string]interface{}
-	var err = yaml.Unmarshal(content, &data)
-	if err != nil {
-		return err
-	}
+// addEntity adds e to the graph unless an entity with the same ID was already
+// read from another manifest, in which case e is reported and ignored.
+func (g *Graph) addEntity(e *Entity) {
+	var existing *Entity
 	var ok bool
-	var kind string
-	kind, ok = data["kind"].(string)
-	if !ok {
-		return nil
+	existing, ok = g.hash[e.ID]
+	if ok {
+		g.duplicates = append(g.duplicates, e.Source)
+		g.loadDiagnostics = append(g.loadDiagnostics, Diagnostic{
+			SeverityWarning,
+			e.Source,
+			fmt.Sprintf("%s is already defined at %s:%d, ignored", e.ID, existing.Source.File, existing.Source.Line),
+		})
+		return
 	}
-	if kind == "List" {
-		var obj interface{}
-		var items []interface{}
-		items, ok = data["items"].([]interface{})
-		if !ok {
-			return nil
-		}
-		for _, obj = range items {
-			content, err = yaml.Marshal(obj)
-			if err != nil {
-				return err
-			}
-			err = g.resolveEntities(content)
-			if err != nil {
-				return err
-			}
-		}
-	} else {
-		var e = &Entity{
-			uid: kindNameUID(data["kind"].(string), (data["metadata"].(map[interface{}]interface{}))["name"].(string)),
-			raw: string(content),
-		}
-		err = yaml.Unmarshal(content, e)
-		if err != nil {
-			return err
-		}
-		g.addEntity(e)
-	}
-	return nil
+	g.entities = append(g.entities, e)
+	g.hash[e.ID] = e
 }
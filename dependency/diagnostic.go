@@ -0,0 +1,90 @@
+package dependency
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Diagnostic is a problem found while building the graph. The graph is still
+// built from everything that could be read; the diagnostics tell what was
+// left out and where.
+type Diagnostic struct {
+	Severity Severity
+	Source   Source
+	Message  string
+}
+
+// Severity tells whether a diagnostic is an error, which means a manifest
+// could not be used, or a warning about something that was ignored on
+// purpose or looks wrong.
+type Severity string
+
+const (
+	// SeverityError marks manifests that could not be read or decoded.
+	SeverityError Severity = "error"
+	// SeverityWarning marks documents skipped because they are not
+	// Kubernetes objects, such as Helm values files or CI configurations,
+	// objects ignored because another manifest already defines them, and
+	// the problems reported by Lint.
+	SeverityWarning Severity = "warning"
+)
+
+func (d Diagnostic) Error() string {
+	if d.Source.File == "" {
+		return d.Message
+	}
+	if d.Source.Line == 0 {
+		return fmt.Sprintf("%s: %s", d.Source.File, d.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", d.Source.File, d.Source.Line, d.Message)
+}
+
+// diagnosticJSON is the JSON form of a Diagnostic, as the page and the API
+// show it. The location is left out when it is unknown.
+type diagnosticJSON struct {
+	Severity Severity `json:"severity"`
+	File     string   `json:"file,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// MarshalJSON encodes the diagnostic as an object with a severity, a file, a
+// line and a message.
+func (d Diagnostic) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&diagnosticJSON{d.Severity, d.Source.File, d.Source.Line, d.Message})
+}
+
+// UnmarshalJSON decodes what MarshalJSON encodes.
+func (d *Diagnostic) UnmarshalJSON(data []byte) error {
+	var obj diagnosticJSON
+	var err = json.Unmarshal(data, &obj)
+	if err != nil {
+		return err
+	}
+	*d = Diagnostic{obj.Severity, Source{obj.File, obj.Line}, obj.Message}
+	return nil
+}
+
+// Diagnostics returns the problems found while reading the manifests,
+// ordered by location. References that cannot be resolved are not among
+// them: they become placeholders, and Lint reports them.
+func (g *Graph) Diagnostics() []Diagnostic {
+	var result = []Diagnostic{}
+	result = append(result, g.loadDiagnostics...)
+	sortDiagnostics(result)
+	return result
+}
+
+func sortDiagnostics(diagnostics []Diagnostic) {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		if diagnostics[i].Source.File != diagnostics[j].Source.File {
+			return diagnostics[i].Source.File < diagnostics[j].Source.File
+		}
+		return diagnostics[i].Source.Line < diagnostics[j].Source.Line
+	})
+}
+
+func (g *Graph) reportLoad(source Source, err error) {
+	g.loadDiagnostics = append(g.loadDiagnostics, Diagnostic{SeverityError, source, err.Error()})
+}
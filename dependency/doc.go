@@ -0,0 +1,24 @@
+// Package dependency builds the dependency graph of the Kubernetes objects
+// defined in a directory of manifests.
+//
+// Every object is an Entity, identified by a Ref made of its namespace, kind
+// and name. Entity IDs are the string form of refs, so they are stable across
+// runs and can be stored. Edges go in the direction of the traffic:
+//
+//	Ingress --ingress-backend--> Service --service-selector--> Deployment
+//	VirtualService --virtualservice-route--> Service
+//	Deployment --service-reference--> Service
+//	Deployment --config-reference--> ConfigMap or Secret
+//	Deployment --volume-claim--> PersistentVolumeClaim
+//
+// Services that are referenced but not defined by any manifest appear as
+// placeholder entities of kind KindUnknownService. ConfigMaps, Secrets and
+// claims no manifest defines are left out instead.
+//
+// Following the edges ranks the entities in tiers, from what receives
+// traffic from outside to what stores configuration and data; see Ranks.
+//
+// The examples read the manifests of testdata/shop: building the graph,
+// looking up an object and what depends on it, and reading fields the graph
+// does not keep from the original object.
+package dependency
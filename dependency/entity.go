@@ -0,0 +1,207 @@
+package dependency
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// KindUnknownService is the kind of the placeholder entities created for
+// Services that are referenced but not defined by any manifest.
+const KindUnknownService = "UnknownService"
+
+// Entity is a Kubernetes object read from a manifest, or a placeholder for an
+// object that is referenced but not defined.
+type Entity struct {
+	// ID identifies the entity in the graph. It is the string form of
+	// Ref and does not change between runs.
+	ID string `yaml:"-"`
+	// Kind is the kind of the object, or KindUnknownService for
+	// placeholders.
+	Kind     string   `yaml:"kind"`
+	Metadata Metadata `yaml:"metadata"`
+	// Source is where the object was read from. It is empty for
+	// placeholders.
+	Source Source `yaml:"-"`
+	// Raw is the YAML document the object was decoded from. It is empty
+	// for placeholders.
+	Raw    string `yaml:"-"`
+	inputs inputs
+	// location is Source relative to the directory the graph was built
+	// from, so that it is the same whichever way that directory was named.
+	location string
+}
+
+// Metadata is the part of an object's metadata the graph keeps.
+type Metadata struct {
+	Name         string            `yaml:"name"`
+	GenerateName string            `yaml:"generateName"`
+	Namespace    string            `yaml:"namespace"`
+	Labels       map[string]string `yaml:"labels"`
+	Annotations  map[string]string `yaml:"annotations"`
+}
+
+// Source is the location of the manifest an entity was read from. Line is
+// the first line of the YAML document in File.
+type Source struct {
+	File string
+	Line int
+}
+
+// Ref identifies an object by its namespace, kind and name. An empty
+// Namespace stands for the "default" namespace.
+type Ref struct {
+	Namespace string
+	Kind      string
+	Name      string
+}
+
+// String returns the ID of the entity the ref identifies, in the form
+// namespace/kind/name.
+func (r Ref) String() string {
+	return EntityID(r.Namespace, r.Kind, r.Name)
+}
+
+// ParseRef parses a ref written as namespace/kind/name, or as kind/name for
+// an object in the "default" namespace.
+func ParseRef(s string) (Ref, error) {
+	var parts = strings.Split(s, "/")
+	var part string
+	for _, part = range parts {
+		if part == "" {
+			return Ref{}, fmt.Errorf("invalid ref %q: empty part", s)
+		}
+	}
+	switch len(parts) {
+	case 2:
+		return Ref{"", parts[0], parts[1]}, nil
+	case 3:
+		return Ref{parts[0], parts[1], parts[2]}, nil
+	}
+	return Ref{}, fmt.Errorf("invalid ref %q: expected namespace/kind/name or kind/name", s)
+}
+
+// Ref returns the ref identifying the entity, the parsed form of its ID.
+func (e *Entity) Ref() Ref {
+	return entityRef(e)
+}
+
+// DisplayName returns the name of the entity. Objects that only have a
+// generateName get a synthesized name made of the prefix and a "*".
+func (e *Entity) DisplayName() string {
+	if e.Metadata.Name == "" && e.Metadata.GenerateName != "" {
+		return e.Metadata.GenerateName + "*"
+	}
+	return e.Metadata.Name
+}
+
+// Replicas returns the number of pods a Deployment asks for, 1 when its
+// manifest leaves it out. Other kinds have no replica count.
+func (e *Entity) Replicas() (int, bool) {
+	if e.Kind != "Deployment" {
+		return 0, false
+	}
+	if e.inputs.Replicas == nil {
+		return 1, true
+	}
+	return *e.inputs.Replicas, true
+}
+
+// BackendPort returns the port of the named Service an Ingress routes to, by
+// number or by name, or "" when the Ingress does not route to it or names no
+// port. The first backend naming the Service wins.
+func (e *Entity) BackendPort(service string) string {
+	var b backend
+	for _, b = range e.inputs.Backends {
+		if b.Service == service {
+			return b.Port
+		}
+	}
+	return ""
+}
+
+// Object decodes Raw into a generic object whose mappings are all
+// map[string]interface{}, so that it can be encoded as JSON. Placeholders
+// decode to nil.
+func (e *Entity) Object() (map[string]interface{}, error) {
+	if e.Raw == "" {
+		return nil, nil
+	}
+	var obj interface{}
+	var err = yaml.Unmarshal([]byte(e.Raw), &obj)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	result, _ = stringKeys(obj).(map[string]interface{})
+	return result, nil
+}
+
+// stringKeys converts the map[interface{}]interface{} mappings produced by
+// the YAML decoder into map[string]interface{}.
+func stringKeys(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		var result = map[string]interface{}{}
+		var key, item interface{}
+		for key, item = range value {
+			result[fmt.Sprint(key)] = stringKeys(item)
+		}
+		return result
+	case []interface{}:
+		var result = make([]interface{}, len(value))
+		var index int
+		var item interface{}
+		for index, item = range value {
+			result[index] = stringKeys(item)
+		}
+		return result
+	}
+	return value
+}
+
+// EntityID returns the ID of the object with the given identity. IDs are
+// derived from the namespace, kind and name only, so they stay the same
+// across runs and when manifests are added, moved or reordered. Objects
+// without a namespace are placed in the "default" namespace, as kubectl
+// would.
+func EntityID(namespace, kind, name string) string {
+	if namespace == "" {
+		namespace = "default"
+	}
+	return fmt.Sprintf("%s/%s/%s", namespace, kind, name)
+}
+
+// entityRef returns the ref of an entity. Placeholders are refs to the
+// Service they stand for. Objects named by the API server from a generateName
+// prefix have no name of their own, so their ref also carries a hash of the
+// manifest location relative to the target.
+func entityRef(e *Entity) Ref {
+	if e.Kind == KindUnknownService {
+		return Ref{e.Metadata.Namespace, "Service", e.Metadata.Name}
+	}
+	if e.Metadata.Name == "" && e.Metadata.GenerateName != "" {
+		return Ref{e.Metadata.Namespace, e.Kind, e.DisplayName() + contentHash([]byte(e.location))[:8]}
+	}
+	return Ref{e.Metadata.Namespace, e.Kind, e.Metadata.Name}
+}
+
+func entityID(e *Entity) string {
+	return entityRef(e).String()
+}
+
+// relativeLocation returns source as a slash separated path relative to
+// root followed by the line, or the path as given when it cannot be made
+// relative.
+func relativeLocation(root string, source Source) string {
+	var path, err = filepath.Abs(source.File)
+	if err == nil {
+		path, err = filepath.Rel(root, path)
+	}
+	if err != nil {
+		path = source.File
+	}
+	return fmt.Sprintf("%s:%d", filepath.ToSlash(path), source.Line)
+}
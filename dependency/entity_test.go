@@ -0,0 +1,110 @@
+package dependency
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const migrationJob = `apiVersion: batch/v1
+kind: Job
+metadata:
+  generateName: migrate-
+  namespace: shop
+`
+
+func TestGenerateNameIDDoesNotDependOnTargetPath(t *testing.T) {
+	var dir = t.TempDir()
+	writeManifests(t, dir, map[string]string{"jobs/migrate.yaml": migrationJob})
+	var cwd, err = os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var relative string
+	relative, err = filepath.Rel(cwd, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var absolute = buildGraph(t, dir, nil).Nodes()
+	var fromRelative = buildGraph(t, relative, nil).Nodes()
+	if len(absolute) != 1 || len(fromRelative) != 1 {
+		t.Fatalf("got %d and %d entities, want 1", len(absolute), len(fromRelative))
+	}
+	if absolute[0].ID != fromRelative[0].ID {
+		t.Fatalf("ID is %s from %s but %s from %s", absolute[0].ID, dir, fromRelative[0].ID, relative)
+	}
+	if absolute[0].Ref() != fromRelative[0].Ref() {
+		t.Fatalf("Ref is %v from %s but %v from %s", absolute[0].Ref(), dir, fromRelative[0].Ref(), relative)
+	}
+}
+
+type backendPortCase struct {
+	ingress string
+	service string
+	port    string
+}
+
+func TestBackendPort(t *testing.T) {
+	var dir = t.TempDir()
+	writeManifests(t, dir, map[string]string{
+		"v1beta1.yaml": `apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: old
+  namespace: shop
+spec:
+  rules:
+  - http:
+      paths:
+      - backend:
+          serviceName: web
+          servicePort: 80
+`,
+		"v1.yaml": `apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: new
+  namespace: shop
+spec:
+  rules:
+  - http:
+      paths:
+      - backend:
+          service:
+            name: web
+            port:
+              name: http
+      - backend:
+          service:
+            name: db
+            port:
+              number: 5432
+`,
+	})
+	var g = buildGraph(t, dir, nil)
+	var tests = []backendPortCase{
+		{"old", "web", "80"},
+		{"new", "web", "http"},
+		{"new", "db", "5432"},
+		{"old", "db", ""},
+	}
+	var test backendPortCase
+	for _, test = range tests {
+		var e = g.Entity(EntityID("shop", "Ingress", test.ingress))
+		if e == nil {
+			t.Fatalf("Ingress %s not found", test.ingress)
+		}
+		var port = e.BackendPort(test.service)
+		if port != test.port {
+			t.Errorf("Ingress %s routes to port %q of %s, want %q", test.ingress, port, test.service, test.port)
+		}
+		var routed = false
+		var other *Entity
+		for _, other = range g.Neighbors(e.Ref(), DirectionOutgoing) {
+			routed = routed || other.ID == EntityID("shop", "Service", test.service)
+		}
+		if routed != (test.port != "") {
+			t.Errorf("Ingress %s has an edge to %s: %v", test.ingress, test.service, routed)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package dependency_test
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+)
+
+func ExampleBuildGraph() {
+	graph, err := dependency.BuildGraph("testdata/shop", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, d := range graph.Diagnostics() {
+		fmt.Println(d)
+	}
+	for _, e := range graph.Nodes() {
+		fmt.Println(e.ID, e.Metadata.Labels)
+	}
+	for _, edge := range graph.Edges() {
+		fmt.Println(edge.From, edge.Kind, edge.To)
+	}
+	// Output:
+	// shop/Service/cart map[]
+	// shop/Deployment/cart map[app:cart]
+	// shop/Ingress/storefront map[]
+	// shop/ConfigMap/cart-settings map[]
+	// shop/Service/payments map[]
+	// shop/Service/cart service-selector shop/Deployment/cart
+	// shop/Deployment/cart service-reference shop/Service/payments
+	// shop/Deployment/cart config-reference shop/ConfigMap/cart-settings
+	// shop/Ingress/storefront ingress-backend shop/Service/cart
+}
+
+func ExampleGraph_Neighbors() {
+	graph, err := dependency.BuildGraph("testdata/shop", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ref, err := dependency.ParseRef("shop/Service/cart")
+	if err != nil {
+		log.Fatal(err)
+	}
+	cart, ok := graph.Get(ref)
+	if !ok {
+		log.Fatalf("%s not found", ref)
+	}
+	for _, e := range graph.Neighbors(cart.Ref(), dependency.DirectionIncoming) {
+		fmt.Printf("%s depends on %s\n", e.ID, cart.ID)
+	}
+	// Output:
+	// shop/Ingress/storefront depends on shop/Service/cart
+}
+
+func ExampleEntity_Object() {
+	graph, err := dependency.BuildGraph("testdata/shop", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cart := graph.Entity("shop/Service/cart")
+	obj, err := cart.Object()
+	if err != nil {
+		log.Fatal(err)
+	}
+	spec, _ := obj["spec"].(map[string]interface{})
+	fmt.Println(spec["ports"])
+	// Output:
+	// [map[port:80 targetPort:8080]]
+}
@@ -0,0 +1,77 @@
+package dependency
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Filter selects entities by name, kind, namespace and labels. The zero
+// Filter selects every entity.
+type Filter struct {
+	// Query is matched loosely against the names: the entity is selected
+	// when the characters of Query appear in its name in the same order,
+	// ignoring case and spaces, so "pgw" finds "payment-gateway".
+	Query string
+	// Kinds, when not empty, lists the kinds of the entities selected.
+	Kinds []string
+	// Namespaces, when not empty, lists the namespaces of the entities
+	// selected.
+	Namespaces []string
+	// Selector must match the labels of the entities selected.
+	Selector Selector
+}
+
+// Matches tells whether the filter selects e.
+func (f *Filter) Matches(e *Entity) bool {
+	if len(f.Kinds) > 0 && !contains(f.Kinds, e.Kind) {
+		return false
+	}
+	if len(f.Namespaces) > 0 && !contains(f.Namespaces, e.Namespace()) {
+		return false
+	}
+	if !f.Selector.Matches(e.Metadata.Labels) {
+		return false
+	}
+	return fuzzyMatch(f.Query, e.DisplayName())
+}
+
+// Filter returns the entities selected by f, in the order of Nodes.
+func (g *Graph) Filter(f Filter) []*Entity {
+	var result = []*Entity{}
+	var e *Entity
+	for _, e = range g.entities {
+		if f.Matches(e) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// Namespace returns the namespace of the entity, "default" when its manifest
+// sets none.
+func (e *Entity) Namespace() string {
+	if e.Metadata.Namespace == "" {
+		return "default"
+	}
+	return e.Metadata.Namespace
+}
+
+// fuzzyMatch tells whether the characters of query, other than spaces,
+// appear in name in the same order, ignoring case.
+func fuzzyMatch(query, name string) bool {
+	var rest = []rune(strings.ToLower(name))
+	var c rune
+	for _, c = range strings.ToLower(query) {
+		if unicode.IsSpace(c) {
+			continue
+		}
+		for len(rest) > 0 && rest[0] != c {
+			rest = rest[1:]
+		}
+		if len(rest) == 0 {
+			return false
+		}
+		rest = rest[1:]
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package dependency
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Reached is an entity found by Reach, with the number of edges between it
+// and the entity the walk started from.
+type Reached struct {
+	Entity   *Entity
+	Distance int
+}
+
+// Reach walks the graph breadth-first from the entity identified by ref,
+// following the edges selected by direction, and returns the entities at
+// most depth edges away, nearest first. The first is the entity itself, at
+// distance 0. Reach returns nil when ref identifies no entity.
+func (g *Graph) Reach(ref Ref, depth int, direction Direction) []Reached {
+	var root, ok = g.Get(ref)
+	if !ok {
+		return nil
+	}
+	var result = []Reached{}
+	var distance = map[string]int{root.ID: 0}
+	var queue = []*Entity{root}
+	for len(queue) > 0 {
+		var e = queue[0]
+		queue = queue[1:]
+		result = append(result, Reached{e, distance[e.ID]})
+		if distance[e.ID] >= depth {
+			continue
+		}
+		var other *Entity
+		for _, other = range g.Neighbors(e.Ref(), direction) {
+			_, ok = distance[other.ID]
+			if !ok {
+				distance[other.ID] = distance[e.ID] + 1
+				queue = append(queue, other)
+			}
+		}
+	}
+	return result
+}
+
+// Focus returns the part of the graph around the entity identified by ref:
+// the entities Reach finds and the edges between them. The result shares its
+// entities with g and keeps the diagnostics of g. It must not be reloaded;
+// reload g and focus again instead.
+func (g *Graph) Focus(ref Ref, depth int, direction Direction) (*Graph, error) {
+	var reached = g.Reach(ref, depth, direction)
+	if reached == nil {
+		return nil, fmt.Errorf("focus: %s not found", ref)
+	}
+	var kept = map[string]bool{}
+	var r Reached
+	for _, r = range reached {
+		kept[r.Entity.ID] = true
+	}
+	var result = Graph{
+		entities:        []*Entity{},
+		hash:            map[string]*Entity{},
+		outgoing:        map[string][]Edge{},
+		incoming:        map[string][]Edge{},
+		labels:          g.labels,
+		options:         g.options,
+		loadDiagnostics: g.loadDiagnostics,
+	}
+	var e *Entity
+	for _, e = range g.entities {
+		if kept[e.ID] {
+			result.entities = append(result.entities, e)
+			result.hash[e.ID] = e
+		}
+	}
+	var edge Edge
+	for _, edge = range g.Edges() {
+		if kept[edge.From] && kept[edge.To] {
+			result.outgoing[edge.From] = append(result.outgoing[edge.From], edge)
+			result.incoming[edge.To] = append(result.incoming[edge.To], edge)
+		}
+	}
+	return &result, nil
+}
+
+// Lookup finds the entity a user names on the command line. Besides the
+// namespace/kind/name form of refs, it accepts kind/name for an object of
+// any namespace, as long as only one namespace has it. Kinds are not case
+// sensitive, so deployment/web finds the Deployment web.
+func (g *Graph) Lookup(s string) (*Entity, error) {
+	var ref, err = ParseRef(s)
+	if err != nil {
+		return nil, err
+	}
+	var found = []*Entity{}
+	var e *Entity
+	for _, e = range g.entities {
+		var other = e.Ref()
+		if !strings.EqualFold(other.Kind, ref.Kind) || other.Name != ref.Name {
+			continue
+		}
+		if ref.Namespace != "" && e.Namespace() != ref.Namespace {
+			continue
+		}
+		found = append(found, e)
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("%s not found", s)
+	case 1:
+		return found[0], nil
+	}
+	var ids = []string{}
+	for _, e = range found {
+		ids = append(ids, e.ID)
+	}
+	sort.Strings(ids)
+	return nil, fmt.Errorf("%s is ambiguous, it could be any of %s", s, strings.Join(ids, ", "))
+}
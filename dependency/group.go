@@ -0,0 +1,127 @@
+package dependency
+
+import (
+	"sort"
+)
+
+// GroupByNamespace is the key grouping entities by namespace. Any other key
+// groups them by the value of the label with that key, such as
+// app.kubernetes.io/part-of for applications or app.kubernetes.io/instance
+// for Helm releases.
+const GroupByNamespace = "namespace"
+
+// Group is a set of entities with the same value for the key of a Grouping.
+type Group struct {
+	// ID is key=value. Entity IDs never contain "=", so group and entity
+	// IDs can be told apart.
+	ID      string
+	Key     string
+	Value   string
+	Members []*Entity
+}
+
+// GroupEdge stands for the edges going from the members of one group to the
+// members of another. Entities outside every group stand for themselves.
+type GroupEdge struct {
+	// From and To are group IDs, or entity IDs for entities in no group.
+	From string
+	To   string
+	// Kinds are the distinct kinds of the edges, sorted.
+	Kinds []EdgeKind
+	// Count is the number of edges.
+	Count int
+}
+
+// Grouping is the partition of the entities of a graph by a namespace or a
+// label key.
+type Grouping struct {
+	Key string
+	// Groups are sorted by value, their members keep the order of Nodes.
+	Groups []*Group
+	// Ungrouped are the entities that have no value for the key.
+	Ungrouped []*Entity
+	// Edges aggregate the edges of the graph between groups and ungrouped
+	// entities, in the order their first edge appears in Edges. Edges
+	// between members of the same group are left out.
+	Edges []GroupEdge
+	of    map[string]*Group
+}
+
+// Group partitions the entities by key: GroupByNamespace, or a label key.
+func (g *Graph) Group(key string) *Grouping {
+	var result = Grouping{Key: key, Groups: []*Group{}, Ungrouped: []*Entity{}, Edges: []GroupEdge{}, of: map[string]*Group{}}
+	var byValue = map[string]*Group{}
+	var e *Entity
+	for _, e = range g.entities {
+		var value string
+		var ok bool
+		if key == GroupByNamespace {
+			value, ok = e.Namespace(), true
+		} else {
+			value, ok = e.Metadata.Labels[key]
+		}
+		if !ok {
+			result.Ungrouped = append(result.Ungrouped, e)
+			continue
+		}
+		var group = byValue[value]
+		if group == nil {
+			group = &Group{ID: key + "=" + value, Key: key, Value: value}
+			byValue[value] = group
+			result.Groups = append(result.Groups, group)
+		}
+		group.Members = append(group.Members, e)
+		result.of[e.ID] = group
+	}
+	sort.Slice(result.Groups, func(i, j int) bool {
+		return result.Groups[i].Value < result.Groups[j].Value
+	})
+	var index = map[[2]string]int{}
+	var edge Edge
+	for _, edge = range g.Edges() {
+		var pair = [2]string{result.node(edge.From), result.node(edge.To)}
+		if result.of[edge.From] != nil && result.of[edge.From] == result.of[edge.To] {
+			continue
+		}
+		var position, ok = index[pair]
+		if !ok {
+			position = len(result.Edges)
+			index[pair] = position
+			result.Edges = append(result.Edges, GroupEdge{From: pair[0], To: pair[1]})
+		}
+		var aggregate = &result.Edges[position]
+		aggregate.Count++
+		if !containsKind(aggregate.Kinds, edge.Kind) {
+			aggregate.Kinds = append(aggregate.Kinds, edge.Kind)
+			sort.Slice(aggregate.Kinds, func(i, j int) bool {
+				return aggregate.Kinds[i] < aggregate.Kinds[j]
+			})
+		}
+	}
+	return &result
+}
+
+// GroupOf returns the group of the entity with the given ID, or nil if it is
+// in no group.
+func (gr *Grouping) GroupOf(id string) *Group {
+	return gr.of[id]
+}
+
+// node returns the ID standing for an entity in the edges of the grouping.
+func (gr *Grouping) node(id string) string {
+	var group = gr.of[id]
+	if group == nil {
+		return id
+	}
+	return group.ID
+}
+
+func containsKind(kinds []EdgeKind, kind EdgeKind) bool {
+	var other EdgeKind
+	for _, other = range kinds {
+		if other == kind {
+			return true
+		}
+	}
+	return false
+}
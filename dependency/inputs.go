@@ -0,0 +1,342 @@
+package dependency
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gkawamoto/kube-second-mate/k8s"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// inputs holds everything needed to resolve the references of an entity. It
+// is parsed once, when the manifest is read, so that resolving references
+// never decodes YAML again.
+type inputs struct {
+	// Selector is the pod selector of a Service.
+	Selector map[string]string
+	// Backends are the Services an Ingress routes to.
+	Backends []backend
+	// References are the Services listed in the kube.references.services
+	// annotation of a workload.
+	References []string
+	// Routes are the Services a VirtualService routes to, which can be in
+	// other namespaces.
+	Routes []Ref
+	// Uses are the ConfigMaps, Secrets and PersistentVolumeClaims the pods
+	// of a workload mount or read environment variables from.
+	Uses []Ref
+	// Replicas is the replica count of a Deployment, nil when the manifest
+	// leaves it to its default.
+	Replicas *int
+}
+
+// parseInputs decodes the parts of the entity's manifest its kind needs.
+func (e *Entity) parseInputs() error {
+	switch e.Kind {
+	case "Ingress":
+		var obj ingress
+		var err = yaml.Unmarshal([]byte(e.Raw), &obj)
+		if err != nil {
+			return err
+		}
+		e.inputs.Backends = obj.backends()
+	case "Service":
+		var obj k8s.Service
+		var err = yaml.Unmarshal([]byte(e.Raw), &obj)
+		if err != nil {
+			return err
+		}
+		e.inputs.Selector = obj.Spec.Selector
+	case "VirtualService":
+		var obj virtualService
+		var err = yaml.Unmarshal([]byte(e.Raw), &obj)
+		if err != nil {
+			return err
+		}
+		var routes []routeDestination
+		for _, routes = range obj.routes() {
+			var route routeDestination
+			for _, route = range routes {
+				var ref, ok = serviceHost(route.Destination.Host, e.Metadata.Namespace)
+				if ok {
+					e.inputs.Routes = append(e.inputs.Routes, ref)
+				}
+			}
+		}
+	case "Deployment", "DaemonSet":
+		var service string
+		for _, service = range strings.Split(e.Metadata.Annotations["kube.references.services"], ",") {
+			service = strings.TrimSpace(service)
+			if service != "" {
+				e.inputs.References = append(e.inputs.References, service)
+			}
+		}
+		var obj workload
+		var err = yaml.Unmarshal([]byte(e.Raw), &obj)
+		if err != nil {
+			return err
+		}
+		e.inputs.Uses = obj.Spec.Template.Spec.uses(e.Metadata.Namespace)
+		if e.Kind == "Deployment" {
+			e.inputs.Replicas = obj.Spec.Replicas
+		}
+	}
+	return nil
+}
+
+// backend is a Service an Ingress routes to, and the port of that Service by
+// number or by name.
+type backend struct {
+	Service string
+	Port    string
+}
+
+// ingress is the part of an Ingress naming its backends, in both the
+// extensions/v1beta1 and the networking.k8s.io/v1 layouts.
+type ingress struct {
+	Spec struct {
+		Rules []struct {
+			HTTP struct {
+				Paths []struct {
+					Backend struct {
+						ServiceName string      `yaml:"serviceName"`
+						ServicePort interface{} `yaml:"servicePort"`
+						Service     struct {
+							Name string `yaml:"name"`
+							Port struct {
+								Number interface{} `yaml:"number"`
+								Name   string      `yaml:"name"`
+							} `yaml:"port"`
+						} `yaml:"service"`
+					} `yaml:"backend"`
+				} `yaml:"paths"`
+			} `yaml:"http"`
+		} `yaml:"rules"`
+	} `yaml:"spec"`
+}
+
+func (i *ingress) backends() []backend {
+	var result []backend
+	var rule, path int
+	for rule = range i.Spec.Rules {
+		var paths = i.Spec.Rules[rule].HTTP.Paths
+		for path = range paths {
+			var b = paths[path].Backend
+			switch {
+			case b.ServiceName != "":
+				result = append(result, backend{b.ServiceName, scalar(b.ServicePort)})
+			case b.Service.Name != "" && b.Service.Port.Number != nil:
+				result = append(result, backend{b.Service.Name, scalar(b.Service.Port.Number)})
+			case b.Service.Name != "":
+				result = append(result, backend{b.Service.Name, b.Service.Port.Name})
+			}
+		}
+	}
+	return result
+}
+
+func scalar(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// virtualService is the part of an Istio VirtualService naming the hosts it
+// routes to.
+type virtualService struct {
+	Spec struct {
+		HTTP []struct {
+			Route []routeDestination `yaml:"route"`
+		} `yaml:"http"`
+		TCP []struct {
+			Route []routeDestination `yaml:"route"`
+		} `yaml:"tcp"`
+		TLS []struct {
+			Route []routeDestination `yaml:"route"`
+		} `yaml:"tls"`
+	} `yaml:"spec"`
+}
+
+type routeDestination struct {
+	Destination struct {
+		Host string `yaml:"host"`
+	} `yaml:"destination"`
+}
+
+func (v *virtualService) routes() [][]routeDestination {
+	var result = [][]routeDestination{}
+	var index int
+	for index = range v.Spec.HTTP {
+		result = append(result, v.Spec.HTTP[index].Route)
+	}
+	for index = range v.Spec.TCP {
+		result = append(result, v.Spec.TCP[index].Route)
+	}
+	for index = range v.Spec.TLS {
+		result = append(result, v.Spec.TLS[index].Route)
+	}
+	return result
+}
+
+// serviceHost returns the Service a host names: a short name for a Service
+// of namespace, or name.namespace.svc optionally followed by .cluster.local.
+// Other hosts are outside of the cluster.
+func serviceHost(host string, namespace string) (Ref, bool) {
+	if host == "" || host == "*" {
+		return Ref{}, false
+	}
+	if !strings.Contains(host, ".") {
+		return Ref{namespace, "Service", host}, true
+	}
+	host = strings.TrimSuffix(host, ".cluster.local")
+	if !strings.HasSuffix(host, ".svc") {
+		return Ref{}, false
+	}
+	var parts = strings.Split(strings.TrimSuffix(host, ".svc"), ".")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return Ref{}, false
+	}
+	return Ref{parts[1], "Service", parts[0]}, true
+}
+
+// workload is the part of a Deployment or DaemonSet naming what its pods
+// use and how many of them it runs.
+type workload struct {
+	Spec struct {
+		Replicas *int `yaml:"replicas"`
+		Template struct {
+			Spec podSpec `yaml:"spec"`
+		} `yaml:"template"`
+	} `yaml:"spec"`
+}
+
+type podSpec struct {
+	Volumes []struct {
+		ConfigMap *struct {
+			Name string `yaml:"name"`
+		} `yaml:"configMap"`
+		Secret *struct {
+			SecretName string `yaml:"secretName"`
+		} `yaml:"secret"`
+		PersistentVolumeClaim *struct {
+			ClaimName string `yaml:"claimName"`
+		} `yaml:"persistentVolumeClaim"`
+	} `yaml:"volumes"`
+	Containers     []container `yaml:"containers"`
+	InitContainers []container `yaml:"initContainers"`
+}
+
+type container struct {
+	EnvFrom []struct {
+		ConfigMapRef *objectName `yaml:"configMapRef"`
+		SecretRef    *objectName `yaml:"secretRef"`
+	} `yaml:"envFrom"`
+	Env []struct {
+		ValueFrom *struct {
+			ConfigMapKeyRef *objectName `yaml:"configMapKeyRef"`
+			SecretKeyRef    *objectName `yaml:"secretKeyRef"`
+		} `yaml:"valueFrom"`
+	} `yaml:"env"`
+}
+
+type objectName struct {
+	Name string `yaml:"name"`
+}
+
+// uses returns the ConfigMaps, Secrets and PersistentVolumeClaims of
+// namespace the pod spec refers to, each once.
+func (s *podSpec) uses(namespace string) []Ref {
+	var result = []Ref{}
+	var seen = map[Ref]bool{}
+	var add = func(kind string, name string) {
+		var ref = Ref{namespace, kind, name}
+		if name != "" && !seen[ref] {
+			seen[ref] = true
+			result = append(result, ref)
+		}
+	}
+	var index int
+	for index = range s.Volumes {
+		var volume = &s.Volumes[index]
+		if volume.ConfigMap != nil {
+			add("ConfigMap", volume.ConfigMap.Name)
+		}
+		if volume.Secret != nil {
+			add("Secret", volume.Secret.SecretName)
+		}
+		if volume.PersistentVolumeClaim != nil {
+			add("PersistentVolumeClaim", volume.PersistentVolumeClaim.ClaimName)
+		}
+	}
+	var c container
+	for _, c = range append(append([]container{}, s.InitContainers...), s.Containers...) {
+		for index = range c.EnvFrom {
+			if c.EnvFrom[index].ConfigMapRef != nil {
+				add("ConfigMap", c.EnvFrom[index].ConfigMapRef.Name)
+			}
+			if c.EnvFrom[index].SecretRef != nil {
+				add("Secret", c.EnvFrom[index].SecretRef.Name)
+			}
+		}
+		for index = range c.Env {
+			var from = c.Env[index].ValueFrom
+			if from == nil {
+				continue
+			}
+			if from.ConfigMapKeyRef != nil {
+				add("ConfigMap", from.ConfigMapKeyRef.Name)
+			}
+			if from.SecretKeyRef != nil {
+				add("Secret", from.SecretKeyRef.Name)
+			}
+		}
+	}
+	return result
+}
+
+// labelIndex maps every "namespace/key=value" label pair to the entities
+// carrying it, so that selectors are matched without scanning every entity.
+type labelIndex map[string][]*Entity
+
+func labelPair(namespace, key, value string) string {
+	if namespace == "" {
+		namespace = "default"
+	}
+	return namespace + "/" + key + "=" + value
+}
+
+func (l labelIndex) add(e *Entity) {
+	var key, value string
+	for key, value = range e.Metadata.Labels {
+		var pair = labelPair(e.Metadata.Namespace, key, value)
+		l[pair] = append(l[pair], e)
+	}
+}
+
+// match returns the entities of namespace whose labels contain every pair of
+// selector. An empty selector matches nothing, like the selector of a
+// Service.
+func (l labelIndex) match(namespace string, selector map[string]string) []*Entity {
+	if len(selector) == 0 {
+		return nil
+	}
+	var pairs = []string{}
+	var key, value string
+	for key, value = range selector {
+		pairs = append(pairs, labelPair(namespace, key, value))
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return len(l[pairs[i]]) < len(l[pairs[j]])
+	})
+	var result = []*Entity{}
+	var matcher = SelectorFromMap(selector)
+	var e *Entity
+	for _, e = range l[pairs[0]] {
+		if matcher.Matches(e.Metadata.Labels) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
@@ -0,0 +1,37 @@
+package dependency
+
+import (
+	"fmt"
+)
+
+// Lint returns the problems of the objects themselves, as opposed to the
+// problems of reading them reported by Diagnostics: references to Services
+// no manifest defines, and Services whose selector matches no workload. They
+// are warnings, ordered by location.
+func (g *Graph) Lint() []Diagnostic {
+	var result = []Diagnostic{}
+	var e *Entity
+	for _, e = range g.entities {
+		if e.Kind == KindUnknownService {
+			var edge Edge
+			for _, edge = range g.incoming[e.ID] {
+				var from = g.hash[edge.From]
+				result = append(result, Diagnostic{
+					SeverityWarning,
+					from.Source,
+					fmt.Sprintf("%s references Service %s, which no manifest defines", from.ID, e.ID),
+				})
+			}
+			continue
+		}
+		if len(e.inputs.Selector) > 0 && len(g.labels.match(e.Metadata.Namespace, e.inputs.Selector)) == 0 {
+			result = append(result, Diagnostic{
+				SeverityWarning,
+				e.Source,
+				fmt.Sprintf("%s selects no workload with %s", e.ID, SelectorFromMap(e.inputs.Selector)),
+			})
+		}
+	}
+	sortDiagnostics(result)
+	return result
+}
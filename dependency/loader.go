@@ -0,0 +1,334 @@
+package dependency
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Options configures how BuildGraph reads manifests. A nil *Options uses the
+// defaults.
+type Options struct {
+	// Workers is the number of files read and decoded concurrently. Zero
+	// means one worker per CPU.
+	Workers int
+	// CacheDir, when set, is a directory where parsed files are kept between
+	// runs. Files whose content did not change are not parsed again.
+	CacheDir string
+}
+
+func (o *Options) workers() int {
+	if o == nil || o.Workers <= 0 {
+		return runtime.NumCPU()
+	}
+	return o.Workers
+}
+
+// fileResult holds the entities and diagnostics read from one file, so that
+// files can be loaded concurrently and merged in a fixed order afterwards.
+type fileResult struct {
+	root        string
+	entities    []*Entity
+	diagnostics []Diagnostic
+}
+
+func (f *fileResult) reportLoad(source Source, err error) {
+	f.diagnostics = append(f.diagnostics, Diagnostic{SeverityError, source, err.Error()})
+}
+
+func (f *fileResult) reportSkipped(source Source, reason string) {
+	var message = fmt.Sprintf("skipped, not a Kubernetes object: %s", reason)
+	f.diagnostics = append(f.diagnostics, Diagnostic{SeverityWarning, source, message})
+}
+
+// retrieveEntities reads every YAML file below target and adds the entities
+// to the graph in the order the files were walked.
+func (g *Graph) retrieveEntities(target string) error {
+	var results, err = g.readFiles(target)
+	if err != nil {
+		return err
+	}
+	var result *fileResult
+	var e *Entity
+	for _, result = range results {
+		for _, e = range result.entities {
+			g.addEntity(e)
+		}
+		g.loadDiagnostics = append(g.loadDiagnostics, result.diagnostics...)
+	}
+	return nil
+}
+
+// targetRoot returns the absolute directory entity locations are relative
+// to: target itself, or the directory holding it when target is a file.
+func targetRoot(target string) (string, error) {
+	var root, err = filepath.Abs(target)
+	if err != nil {
+		return "", err
+	}
+	var info os.FileInfo
+	info, err = os.Stat(root)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		root = filepath.Dir(root)
+	}
+	return root, nil
+}
+
+// readFiles reads every YAML file below target with a pool of workers and
+// returns what each file produced in the order the files were walked, so
+// that the result does not depend on which worker finished first.
+func (g *Graph) readFiles(target string) ([]*fileResult, error) {
+	var paths = []string{}
+	var err = filepath.Walk(target, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path == target {
+				return err
+			}
+			g.reportLoad(Source{File: path}, err)
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if !strings.HasSuffix(info.Name(), ".yaml") && !strings.HasSuffix(info.Name(), ".yml") {
+			return nil
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var cache = newFileCache(g.options)
+	var results = make([]*fileResult, len(paths))
+	var jobs = make(chan int)
+	var wait sync.WaitGroup
+	var worker int
+	for worker = 0; worker < g.options.workers(); worker++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			var index int
+			for index = range jobs {
+				results[index] = loadFile(paths[index], g.root, cache)
+			}
+		}()
+	}
+	var index int
+	for index = range paths {
+		jobs <- index
+	}
+	close(jobs)
+	wait.Wait()
+	return results, nil
+}
+
+// loadFile parses the manifests in path, or takes them from cache when the
+// file did not change since it was cached. root is the directory the graph
+// is built from.
+func loadFile(path string, root string, cache *fileCache) *fileResult {
+	var result = &fileResult{root: root}
+	var data []byte
+	var err error
+	data, err = ioutil.ReadFile(path)
+	if err != nil {
+		result.reportLoad(Source{File: path}, fmt.Errorf("readfile: %s", err))
+		return result
+	}
+	var hash = contentHash(data)
+	var cached = cache.get(path, root, hash)
+	if cached != nil {
+		return cached
+	}
+	defer cache.put(path, hash, result)
+	var doc document
+	for _, doc = range splitDocuments(data) {
+		var source = Source{File: path, Line: doc.line}
+		err = result.resolveEntities(doc.content, source)
+		if err != nil {
+			result.reportLoad(source, err)
+		}
+	}
+	return result
+}
+
+type document struct {
+	content []byte
+	line    int
+}
+
+// splitDocuments splits a multi-document YAML stream on "---" separators,
+// keeping the line each document starts at.
+func splitDocuments(content []byte) []document {
+	var result = []document{}
+	var current []string
+	var start = 1
+	var lines = strings.Split(string(content), "\n")
+	var index int
+	var line string
+	for index, line = range lines {
+		var trimmed = strings.TrimRight(line, " \t\r")
+		if trimmed == "---" || strings.HasPrefix(trimmed, "--- ") {
+			if len(current) > 0 {
+				result = append(result, document{[]byte(strings.Join(current, "\n")), start})
+			}
+			current = nil
+			start = index + 2
+			continue
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		result = append(result, document{[]byte(strings.Join(current, "\n")), start})
+	}
+	return result
+}
+
+func (f *fileResult) resolveEntities(content []byte, source Source) error {
+	var obj interface{}
+	var err = yaml.Unmarshal(content, &obj)
+	if err != nil {
+		return err
+	}
+	if obj == nil {
+		return nil
+	}
+	var data map[interface{}]interface{}
+	var ok bool
+	data, ok = obj.(map[interface{}]interface{})
+	if !ok {
+		f.reportSkipped(source, "document is not a mapping")
+		return nil
+	}
+	var kind, apiVersion interface{}
+	kind, ok = data["kind"]
+	if !ok {
+		f.reportSkipped(source, "no kind")
+		return nil
+	}
+	apiVersion, ok = data["apiVersion"]
+	if !ok {
+		f.reportSkipped(source, "no apiVersion")
+		return nil
+	}
+	_, ok = apiVersion.(string)
+	if !ok {
+		return &ParseError{"apiVersion", fmt.Sprintf("expected a string, got %s", describe(apiVersion))}
+	}
+	var kindString string
+	kindString, ok = kind.(string)
+	if !ok {
+		return &ParseError{"kind", fmt.Sprintf("expected a string, got %s", describe(kind))}
+	}
+	if kindString == "List" {
+		var item interface{}
+		var items []interface{}
+		items, ok = data["items"].([]interface{})
+		if !ok {
+			return &ParseError{"items", fmt.Sprintf("expected a list, got %s", describe(data["items"]))}
+		}
+		var index int
+		for index, item = range items {
+			content, err = yaml.Marshal(item)
+			if err == nil {
+				err = f.resolveEntities(content, source)
+			}
+			if err != nil {
+				f.reportLoad(source, fmt.Errorf("items[%d]: %s", index, err))
+			}
+		}
+		return nil
+	}
+	err = checkMetadata(data["metadata"])
+	if err != nil {
+		return err
+	}
+	var e = &Entity{
+		Source: source,
+		Raw:    string(content),
+	}
+	err = yaml.Unmarshal(content, e)
+	if err != nil {
+		return err
+	}
+	e.location = relativeLocation(f.root, source)
+	e.ID = entityID(e)
+	f.entities = append(f.entities, e)
+	err = e.parseInputs()
+	if err != nil {
+		return fmt.Errorf("%s: %s", e.ID, err)
+	}
+	return nil
+}
+
+// ParseError describes a manifest field that is missing or has an unexpected
+// type.
+type ParseError struct {
+	Field  string
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Reason)
+}
+
+// checkMetadata makes sure the object has a usable name: a metadata.name
+// string, or a metadata.generateName string for objects named by the API
+// server.
+func checkMetadata(metadata interface{}) error {
+	if metadata == nil {
+		return &ParseError{"metadata", "missing"}
+	}
+	var fields map[interface{}]interface{}
+	var ok bool
+	fields, ok = metadata.(map[interface{}]interface{})
+	if !ok {
+		return &ParseError{"metadata", fmt.Sprintf("expected a mapping, got %s", describe(metadata))}
+	}
+	var field string
+	for _, field = range []string{"name", "generateName", "namespace"} {
+		var value interface{}
+		value, ok = fields[field]
+		if !ok {
+			continue
+		}
+		_, ok = value.(string)
+		if !ok {
+			return &ParseError{"metadata." + field, fmt.Sprintf("expected a string, got %s", describe(value))}
+		}
+	}
+	if fields["name"] == nil && fields["generateName"] == nil {
+		return &ParseError{"metadata.name", "missing"}
+	}
+	return nil
+}
+
+func describe(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "nothing"
+	case map[interface{}]interface{}:
+		return "a mapping"
+	case []interface{}:
+		return "a list"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case int, int64, uint64, float64:
+		return "a number"
+	}
+	return fmt.Sprintf("%T", value)
+}
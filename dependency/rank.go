@@ -0,0 +1,86 @@
+package dependency
+
+// Ranks assigns every entity a tier following the direction of the edges,
+// keyed by entity ID. Entities no edge leads to, such as Ingresses, have rank
+// 0, and every other entity is ranked one after the farthest entity leading
+// to it: Services come after the Ingresses and VirtualServices routing to
+// them, workloads after the Services selecting them, and the ConfigMaps,
+// Secrets and claims they use after the workloads. Edges closing a cycle are
+// ignored. Entities without edges are ranked after all the others.
+func (g *Graph) Ranks() map[string]int {
+	var forward = g.acyclicEdges()
+	var result = map[string]int{}
+	var incoming = map[string]int{}
+	var outgoing = map[string][]string{}
+	var edge Edge
+	for _, edge = range forward {
+		incoming[edge.To]++
+		outgoing[edge.From] = append(outgoing[edge.From], edge.To)
+	}
+	var queue = []string{}
+	var e *Entity
+	for _, e = range g.entities {
+		result[e.ID] = 0
+		if incoming[e.ID] == 0 {
+			queue = append(queue, e.ID)
+		}
+	}
+	var last = -1
+	for len(queue) > 0 {
+		var id = queue[0]
+		queue = queue[1:]
+		if len(g.outgoing[id])+len(g.incoming[id]) > 0 && result[id] > last {
+			last = result[id]
+		}
+		var to string
+		for _, to = range outgoing[id] {
+			if result[id]+1 > result[to] {
+				result[to] = result[id] + 1
+			}
+			incoming[to]--
+			if incoming[to] == 0 {
+				queue = append(queue, to)
+			}
+		}
+	}
+	for _, e = range g.entities {
+		if len(g.outgoing[e.ID])+len(g.incoming[e.ID]) == 0 {
+			result[e.ID] = last + 1
+		}
+	}
+	return result
+}
+
+// acyclicEdges returns the edges of the graph without those found to close a
+// cycle by a depth-first search in the order of Nodes.
+func (g *Graph) acyclicEdges() []Edge {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	var state = map[string]int{}
+	var result = []Edge{}
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		var edge Edge
+		for _, edge = range g.outgoing[id] {
+			switch state[edge.To] {
+			case visiting:
+				continue
+			case unvisited:
+				visit(edge.To)
+			}
+			result = append(result, edge)
+		}
+		state[id] = visited
+	}
+	var e *Entity
+	for _, e = range g.entities {
+		if state[e.ID] == unvisited {
+			visit(e.ID)
+		}
+	}
+	return result
+}
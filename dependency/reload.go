@@ -0,0 +1,125 @@
+package dependency
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Reload updates the graph after the given files or directories changed on
+// disk. Only the entities read from those paths are parsed again. Paths that
+// no longer exist remove their entities from the graph.
+//
+// When several manifests define the same object, the one read first by
+// BuildGraph is kept, whichever of them changed.
+func (g *Graph) Reload(paths ...string) error {
+	paths = append([]string{}, paths...)
+	var source Source
+	for _, source = range g.duplicates {
+		// The entity a duplicate was ignored for may be among the changes.
+		paths = append(paths, source.File)
+	}
+	g.duplicates = nil
+	paths = outermost(paths)
+	var entities = []*Entity{}
+	var entity *Entity
+	for _, entity = range g.entities {
+		if entity.Source.File == "" || withinAny(entity.Source.File, paths) {
+			continue
+		}
+		entities = append(entities, entity)
+	}
+	var diagnostics = []Diagnostic{}
+	var diagnostic Diagnostic
+	for _, diagnostic = range g.loadDiagnostics {
+		if !withinAny(diagnostic.Source.File, paths) {
+			diagnostics = append(diagnostics, diagnostic)
+		}
+	}
+	g.loadDiagnostics = diagnostics
+	var results []*fileResult
+	var result *fileResult
+	var err error
+	var path string
+	for _, path = range paths {
+		_, err = os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		results, err = g.readFiles(path)
+		if err != nil {
+			g.reportLoad(Source{File: path}, err)
+			continue
+		}
+		for _, result = range results {
+			entities = append(entities, result.entities...)
+			g.loadDiagnostics = append(g.loadDiagnostics, result.diagnostics...)
+		}
+	}
+	// Adding the entities in the order BuildGraph walks the files makes the
+	// same manifest win when an object is defined twice.
+	sort.SliceStable(entities, func(i, j int) bool {
+		if entities[i].Source.File != entities[j].Source.File {
+			return walkedBefore(entities[i].Source.File, entities[j].Source.File)
+		}
+		return entities[i].Source.Line < entities[j].Source.Line
+	})
+	g.entities = []*Entity{}
+	g.hash = map[string]*Entity{}
+	for _, entity = range entities {
+		g.addEntity(entity)
+	}
+	return g.buildEdges()
+}
+
+// walkedBefore tells whether filepath.Walk visits file a before file b, which
+// compares their paths one element at a time.
+func walkedBefore(a, b string) bool {
+	var first = strings.Split(filepath.Clean(a), string(filepath.Separator))
+	var second = strings.Split(filepath.Clean(b), string(filepath.Separator))
+	var index int
+	for index = 0; index < len(first) && index < len(second); index++ {
+		if first[index] != second[index] {
+			return first[index] < second[index]
+		}
+	}
+	return len(first) < len(second)
+}
+
+// withinAny tells whether file is one of paths or inside one of them.
+func withinAny(file string, paths []string) bool {
+	file = filepath.Clean(file)
+	var path string
+	for _, path = range paths {
+		path = filepath.Clean(path)
+		if file == path || strings.HasPrefix(file, path+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// outermost removes the paths that are repeated or inside another path, so
+// that no file is read twice.
+func outermost(paths []string) []string {
+	var unique = []string{}
+	var seen = map[string]bool{}
+	var path string
+	for _, path = range paths {
+		path = filepath.Clean(path)
+		if !seen[path] {
+			seen[path] = true
+			unique = append(unique, path)
+		}
+	}
+	var result = []string{}
+	var index int
+	for index, path = range unique {
+		var others = append(append([]string{}, unique[:index]...), unique[index+1:]...)
+		if !withinAny(path, others) {
+			result = append(result, path)
+		}
+	}
+	return result
+}
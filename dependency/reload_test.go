@@ -0,0 +1,189 @@
+package dependency
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const (
+	webService = `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: shop
+spec:
+  selector:
+    app: web
+`
+	webDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+  labels:
+    app: web
+  annotations:
+    kube.references.services: db
+spec:
+  template:
+    spec:
+      containers:
+      - envFrom:
+        - configMapRef:
+            name: settings
+`
+	dbService = `apiVersion: v1
+kind: Service
+metadata:
+  name: db
+  namespace: shop
+`
+	settingsConfigMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: shop
+`
+	webIngress = `apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: web
+  namespace: shop
+spec:
+  rules:
+  - http:
+      paths:
+      - backend:
+          serviceName: web
+`
+)
+
+// writeManifests writes files below dir, creating their directories. An
+// empty content removes the file.
+func writeManifests(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	var name, content string
+	for name, content = range files {
+		var path = filepath.Join(dir, name)
+		var err error
+		if content == "" {
+			err = os.Remove(path)
+		} else {
+			err = os.MkdirAll(filepath.Dir(path), 0755)
+			if err == nil {
+				err = ioutil.WriteFile(path, []byte(content), 0644)
+			}
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// describeGraph returns the entities, edges and diagnostics of g one per
+// line, sorted so that graphs holding the same objects compare equal
+// whatever order they were added in.
+func describeGraph(g *Graph) string {
+	var lines = []string{}
+	var e *Entity
+	for _, e = range g.Nodes() {
+		var replicas, _ = e.Replicas()
+		var in = e.inputs
+		in.Replicas = nil
+		lines = append(lines, fmt.Sprintf("entity %s %s %s:%d replicas=%d inputs=%v", e.ID, e.Kind, e.Source.File, e.Source.Line, replicas, in))
+	}
+	var edge Edge
+	for _, edge = range g.Edges() {
+		lines = append(lines, fmt.Sprintf("edge %s -> %s %s", edge.From, edge.To, edge.Kind))
+	}
+	var diagnostic Diagnostic
+	for _, diagnostic = range g.Diagnostics() {
+		lines = append(lines, "diagnostic "+diagnostic.Error())
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+func buildGraph(t *testing.T, dir string, options *Options) *Graph {
+	t.Helper()
+	var g, err = BuildGraph(dir, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestReloadMatchesFreshLoad(t *testing.T) {
+	var steps = []struct {
+		name  string
+		files map[string]string
+	}{
+		{"modify a file", map[string]string{
+			"web.yaml": webService + "---\n" + strings.Replace(webDeployment, "app: web", "app: other", 1),
+		}},
+		{"delete a referenced service", map[string]string{"db.yaml": ""}},
+		{"define the service again", map[string]string{"db.yaml": dbService}},
+		{"add a file in a new directory", map[string]string{"extra/ingress.yaml": webIngress}},
+		{"duplicate an object", map[string]string{"copy/settings.yaml": settingsConfigMap}},
+		{"modify the duplicate", map[string]string{"copy/settings.yaml": settingsConfigMap + "  labels:\n    copy: \"true\"\n"}},
+		{"delete the original of the duplicate", map[string]string{"settings.yaml": ""}},
+		{"delete the service the ingress routes to", map[string]string{"web.yaml": webDeployment}},
+		{"break a manifest", map[string]string{"db.yaml": "kind: Service\nmetadata: [\n"}},
+		{"fix the manifest", map[string]string{"db.yaml": dbService}},
+	}
+	var dir = t.TempDir()
+	writeManifests(t, dir, map[string]string{
+		"web.yaml":      webService + "---\n" + webDeployment,
+		"db.yaml":       dbService,
+		"settings.yaml": settingsConfigMap,
+	})
+	var g = buildGraph(t, dir, nil)
+	var index int
+	for index = range steps {
+		var step = steps[index]
+		writeManifests(t, dir, step.files)
+		var paths = []string{}
+		var name string
+		for name = range step.files {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+		var err = g.Reload(paths...)
+		if err != nil {
+			t.Fatalf("%s: %s", step.name, err)
+		}
+		var got, want = describeGraph(g), describeGraph(buildGraph(t, dir, nil))
+		if got != want {
+			t.Fatalf("%s: reloaded graph differs from a fresh load\ngot:\n%s\nwant:\n%s", step.name, got, want)
+		}
+	}
+}
+
+func TestReloadDirectory(t *testing.T) {
+	var dir = t.TempDir()
+	writeManifests(t, dir, map[string]string{
+		"app/web.yaml":  webService + "---\n" + webDeployment,
+		"app/db.yaml":   dbService,
+		"settings.yaml": settingsConfigMap,
+	})
+	var g = buildGraph(t, dir, nil)
+	writeManifests(t, dir, map[string]string{
+		"app/db.yaml":      "",
+		"app/ingress.yaml": webIngress,
+	})
+	var err = g.Reload(filepath.Join(dir, "app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want = describeGraph(g), describeGraph(buildGraph(t, dir, nil))
+	if got != want {
+		t.Fatalf("reloaded graph differs from a fresh load\ngot:\n%s\nwant:\n%s", got, want)
+	}
+	if g.Entity(EntityID("shop", "Service", "db")).Kind != KindUnknownService {
+		t.Fatalf("deleted service was not replaced by a placeholder")
+	}
+}
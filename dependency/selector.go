@@ -0,0 +1,259 @@
+package dependency
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Selector is a Kubernetes label selector. It is a list of requirements that
+// labels must all meet, written as kubectl accepts them:
+//
+//	app=web,tier!=cache
+//	environment in (production,staging),!canary
+//
+// The zero Selector has no requirement and matches every set of labels.
+type Selector struct {
+	requirements []requirement
+}
+
+type operator string
+
+const (
+	operatorEquals       operator = "="
+	operatorNotEquals    operator = "!="
+	operatorIn           operator = "in"
+	operatorNotIn        operator = "notin"
+	operatorExists       operator = "exists"
+	operatorDoesNotExist operator = "!"
+)
+
+type requirement struct {
+	key      string
+	operator operator
+	values   []string
+}
+
+// ParseSelector parses a label selector. It accepts the equality-based
+// requirements key=value, key==value and key!=value, the set-based
+// requirements key in (a,b) and key notin (a,b), and key and !key for the
+// presence of a label. Requirements are separated by commas.
+func ParseSelector(s string) (Selector, error) {
+	var p = selectorParser{input: s}
+	var result Selector
+	var err error
+	result.requirements, err = p.parse()
+	if err != nil {
+		return Selector{}, fmt.Errorf("invalid selector %q: %s", s, err)
+	}
+	return result, nil
+}
+
+// SelectorFromMap returns the selector requiring every key of labels to have
+// the given value, such as the selector of a Service.
+func SelectorFromMap(labels map[string]string) Selector {
+	var keys = []string{}
+	var key string
+	for key = range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var result Selector
+	for _, key = range keys {
+		result.requirements = append(result.requirements, requirement{key, operatorEquals, []string{labels[key]}})
+	}
+	return result
+}
+
+// Empty tells whether the selector has no requirement.
+func (s Selector) Empty() bool {
+	return len(s.requirements) == 0
+}
+
+// Matches tells whether labels meet every requirement of the selector. As in
+// Kubernetes, key!=value and key notin (...) are met by labels without key.
+func (s Selector) Matches(labels map[string]string) bool {
+	var r requirement
+	for _, r = range s.requirements {
+		if !r.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the selector in the syntax ParseSelector reads.
+func (s Selector) String() string {
+	var parts = []string{}
+	var r requirement
+	for _, r = range s.requirements {
+		parts = append(parts, r.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+func (r requirement) matches(labels map[string]string) bool {
+	var value, ok = labels[r.key]
+	switch r.operator {
+	case operatorEquals, operatorIn:
+		return ok && contains(r.values, value)
+	case operatorNotEquals, operatorNotIn:
+		return !ok || !contains(r.values, value)
+	case operatorExists:
+		return ok
+	case operatorDoesNotExist:
+		return !ok
+	}
+	return false
+}
+
+func (r requirement) String() string {
+	switch r.operator {
+	case operatorEquals, operatorNotEquals:
+		return r.key + string(r.operator) + r.values[0]
+	case operatorIn, operatorNotIn:
+		return r.key + " " + string(r.operator) + " (" + strings.Join(r.values, ",") + ")"
+	case operatorDoesNotExist:
+		return "!" + r.key
+	}
+	return r.key
+}
+
+func contains(values []string, value string) bool {
+	var other string
+	for _, other = range values {
+		if other == value {
+			return true
+		}
+	}
+	return false
+}
+
+// selectorParser reads a selector one token at a time. Tokens are the
+// operators "=", "==", "!=" and "!", parentheses, commas, and words made of
+// any other characters but spaces.
+type selectorParser struct {
+	input    string
+	position int
+}
+
+func (p *selectorParser) parse() ([]requirement, error) {
+	var result = []requirement{}
+	if strings.TrimSpace(p.input) == "" {
+		return result, nil
+	}
+	for {
+		var r, err = p.requirement()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, r)
+		var token = p.next()
+		if token == "" {
+			return result, nil
+		}
+		if token != "," {
+			return nil, fmt.Errorf("expected \",\" after %s, found %q", r, token)
+		}
+	}
+}
+
+func (p *selectorParser) requirement() (requirement, error) {
+	var token = p.next()
+	if token == "!" {
+		var key = p.next()
+		if !isWord(key) {
+			return requirement{}, fmt.Errorf("expected a label key after \"!\", found %q", key)
+		}
+		return requirement{key, operatorDoesNotExist, nil}, nil
+	}
+	if !isWord(token) {
+		return requirement{}, fmt.Errorf("expected a label key, found %q", token)
+	}
+	var result = requirement{key: token, operator: operatorExists}
+	var mark = p.position
+	switch p.next() {
+	case "=", "==":
+		result.operator = operatorEquals
+	case "!=":
+		result.operator = operatorNotEquals
+	case "in":
+		result.operator = operatorIn
+	case "notin":
+		result.operator = operatorNotIn
+	default:
+		p.position = mark
+		return result, nil
+	}
+	if result.operator == operatorIn || result.operator == operatorNotIn {
+		var err error
+		result.values, err = p.set()
+		return result, err
+	}
+	// An empty value is allowed, it matches labels set to "".
+	mark = p.position
+	var value = p.next()
+	if !isWord(value) {
+		p.position = mark
+		value = ""
+	}
+	result.values = []string{value}
+	return result, nil
+}
+
+// set reads the parenthesized values of an in or notin requirement.
+func (p *selectorParser) set() ([]string, error) {
+	var token = p.next()
+	if token != "(" {
+		return nil, fmt.Errorf("expected \"(\", found %q", token)
+	}
+	var result = []string{}
+	for {
+		token = p.next()
+		if isWord(token) {
+			result = append(result, token)
+			token = p.next()
+		}
+		switch token {
+		case ")":
+			if len(result) == 0 {
+				return nil, fmt.Errorf("expected at least one value in parentheses")
+			}
+			return result, nil
+		case ",":
+		default:
+			return nil, fmt.Errorf("expected \",\" or \")\", found %q", token)
+		}
+	}
+}
+
+// next returns the next token, or "" at the end of the input.
+func (p *selectorParser) next() string {
+	var rest = strings.TrimLeftFunc(p.input[p.position:], unicode.IsSpace)
+	p.position = len(p.input) - len(rest)
+	if rest == "" {
+		return ""
+	}
+	var operator string
+	for _, operator = range []string{"==", "!=", "=", "!", "(", ")", ","} {
+		if strings.HasPrefix(rest, operator) {
+			p.position += len(operator)
+			return operator
+		}
+	}
+	var end = strings.IndexFunc(rest, isDelimiter)
+	if end < 0 {
+		end = len(rest)
+	}
+	p.position += end
+	return rest[:end]
+}
+
+func isDelimiter(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune("=!(),", r)
+}
+
+func isWord(token string) bool {
+	return token != "" && strings.IndexFunc(token, isDelimiter) < 0
+}
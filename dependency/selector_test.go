@@ -0,0 +1,64 @@
+package dependency
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+)
+
+// selectorCase is a case of testdata/selectors.json, which the selectors of
+// exported reports are tested against as well.
+type selectorCase struct {
+	Selector string            `json:"selector"`
+	Labels   map[string]string `json:"labels"`
+	Matches  bool              `json:"matches"`
+	Error    bool              `json:"error"`
+}
+
+func TestParseSelector(t *testing.T) {
+	var data, err = ioutil.ReadFile("testdata/selectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cases []selectorCase
+	err = json.Unmarshal(data, &cases)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c selectorCase
+	for _, c = range cases {
+		var s Selector
+		s, err = ParseSelector(c.Selector)
+		if c.Error {
+			if err == nil {
+				t.Errorf("ParseSelector(%q) = %q, want an error", c.Selector, s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseSelector(%q): %s", c.Selector, err)
+			continue
+		}
+		if s.Matches(c.Labels) != c.Matches {
+			t.Errorf("ParseSelector(%q).Matches(%v) = %t, want %t", c.Selector, c.Labels, !c.Matches, c.Matches)
+		}
+		var again Selector
+		again, err = ParseSelector(s.String())
+		if err != nil || again.String() != s.String() {
+			t.Errorf("ParseSelector(%q).String() = %q, which reads back as %q, %v", c.Selector, s, again, err)
+		}
+	}
+}
+
+func TestSelectorFromMap(t *testing.T) {
+	var s = SelectorFromMap(map[string]string{"tier": "front", "app": "web"})
+	if s.String() != "app=web,tier=front" {
+		t.Errorf("got %q, want app=web,tier=front", s)
+	}
+	if !s.Matches(map[string]string{"app": "web", "tier": "front", "extra": ""}) || s.Matches(map[string]string{"app": "web"}) {
+		t.Errorf("%q does not match as a Service selector", s)
+	}
+	if !SelectorFromMap(nil).Empty() {
+		t.Errorf("selector of no labels is not empty")
+	}
+}
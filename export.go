@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+	"github.com/gkawamoto/k8s-visualizer/export"
+	"github.com/gkawamoto/k8s-visualizer/placement"
+	"github.com/gkawamoto/k8s-visualizer/style"
+)
+
+func newExportCommand(options *dependency.Options, focus *focusOptions, layout *string, styleFile *string) *cobra.Command {
+	var format string
+	var output string
+	var groupBy string
+	var collapse bool
+	var cmd = &cobra.Command{
+		Use:   "export <directory>",
+		Short: "Write the graph as a diagram or in a graph exchange format",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var pageLayout, err = placement.ParseLayout(*layout)
+			if err != nil {
+				return err
+			}
+			var styles *style.Registry
+			styles, err = loadStyles(*styleFile)
+			if err != nil {
+				return err
+			}
+			// The arguments were valid; errors from here on are not
+			// usage errors.
+			cmd.SilenceUsage = true
+			var graph *dependency.Graph
+			graph, err = loadGraph(args[0], options, focus)
+			if err != nil {
+				return err
+			}
+			return writeOutput(output, func(out io.Writer) error {
+				return export.Write(out, graph, export.Format(format), &export.Options{
+					Title:    targetTitle(args[0]),
+					GroupBy:  groupBy,
+					Collapse: collapse,
+					Layout:   pageLayout,
+					Styles:   styles,
+				})
+			})
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", "dot", fmt.Sprintf("output format, one of %s", strings.Join(export.Formats(), ", ")))
+	cmd.Flags().StringVarP(&output, "output", "o", "-", "file to write to, or - for the standard output")
+	cmd.Flags().StringVar(&groupBy, "group-by", dependency.GroupByNamespace, "cluster diagram nodes by namespace, or by the value of this label key, such as app.kubernetes.io/part-of")
+	cmd.Flags().BoolVar(&collapse, "collapse", false, "draw every cluster of a diagram as a single node")
+	return cmd
+}
+
+// loadGraph builds the graph of target, narrowed to the neighborhood focus
+// selects, and prints the problems found in the manifests to the standard
+// error.
+func loadGraph(target string, options *dependency.Options, focus *focusOptions) (*dependency.Graph, error) {
+	var graph *dependency.Graph
+	var err error
+	graph, err = dependency.BuildGraph(target, options)
+	if err != nil {
+		return nil, err
+	}
+	var d dependency.Diagnostic
+	for _, d = range graph.Diagnostics() {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", d.Severity, d.Error())
+	}
+	return focus.apply(graph)
+}
+
+// writeOutput calls write with the named file, or with the standard output
+// when path is "-".
+func writeOutput(path string, write func(out io.Writer) error) error {
+	if path == "-" {
+		return write(os.Stdout)
+	}
+	var file *os.File
+	var err error
+	file, err = os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = write(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func targetTitle(target string) string {
+	var abs, err = filepath.Abs(target)
+	if err != nil {
+		return target
+	}
+	return filepath.Base(abs)
+}
@@ -0,0 +1,115 @@
+package export
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+)
+
+// port is a port exposed by a Service or by the containers of a workload.
+type port struct {
+	Name     string `json:"name,omitempty"`
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+}
+
+func (p port) String() string {
+	return fmt.Sprintf("%d/%s", p.Port, p.Protocol)
+}
+
+// entityPorts returns the ports e exposes, read from its manifest: the ports
+// of a Service, or the container ports of a workload's pod template.
+func entityPorts(e *dependency.Entity) []port {
+	var obj, err = e.Object()
+	if err != nil || obj == nil {
+		return nil
+	}
+	var spec = mapping(obj["spec"])
+	if e.Kind == "Service" {
+		return parsePorts(spec["ports"], "port")
+	}
+	var template = mapping(mapping(spec["template"])["spec"])
+	if e.Kind == "Pod" {
+		template = spec
+	}
+	var result []port
+	var container interface{}
+	for _, container = range sequence(template["containers"]) {
+		result = append(result, parsePorts(mapping(container)["ports"], "containerPort")...)
+	}
+	return result
+}
+
+func parsePorts(value interface{}, numberField string) []port {
+	var result []port
+	var item interface{}
+	for _, item = range sequence(value) {
+		var fields = mapping(item)
+		var number, ok = integer(fields[numberField])
+		if !ok {
+			continue
+		}
+		var obj = port{Port: number, Protocol: "TCP"}
+		obj.Name, _ = fields["name"].(string)
+		var protocol string
+		protocol, ok = fields["protocol"].(string)
+		if ok {
+			obj.Protocol = protocol
+		}
+		result = append(result, obj)
+	}
+	return result
+}
+
+// edgePort returns the port of the Service an Ingress backend routes to, by
+// number or by name, or "" when the edge is not an Ingress backend.
+func edgePort(graph *dependency.Graph, edge dependency.Edge) string {
+	if edge.Kind != dependency.EdgeKindIngressBackend {
+		return ""
+	}
+	return graph.Entity(edge.From).BackendPort(graph.Entity(edge.To).Metadata.Name)
+}
+
+// sortedLabelKeys returns every label key used in graph, sorted, so that
+// formats declaring their attributes up front can declare one per key.
+func sortedLabelKeys(graph *dependency.Graph) []string {
+	var seen = map[string]bool{}
+	var result = []string{}
+	var e *dependency.Entity
+	var key string
+	for _, e = range graph.Nodes() {
+		for key = range e.Metadata.Labels {
+			if !seen[key] {
+				seen[key] = true
+				result = append(result, key)
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func mapping(value interface{}) map[string]interface{} {
+	var result, _ = value.(map[string]interface{})
+	return result
+}
+
+func sequence(value interface{}) []interface{} {
+	var result, _ = value.([]interface{})
+	return result
+}
+
+// integer returns value as an int, whichever integer type the YAML decoder
+// chose for it.
+func integer(value interface{}) (int, bool) {
+	switch value := value.(type) {
+	case int:
+		return value, true
+	case int64:
+		return int(value), true
+	case uint64:
+		return int(value), true
+	}
+	return 0, false
+}
@@ -0,0 +1,126 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+	"github.com/gkawamoto/k8s-visualizer/style"
+)
+
+// diagramFormats are the formats drawing a diagram, which group nodes into
+// clusters. The other formats describe the graph itself.
+var diagramFormats = map[Format]bool{
+	FormatDOT:      true,
+	FormatMermaid:  true,
+	FormatPlantUML: true,
+}
+
+func diagramFormatNames() []string {
+	return []string{string(FormatDOT), string(FormatMermaid), string(FormatPlantUML)}
+}
+
+// diagram is what the diagram formats draw, worked out once from the graph
+// and the grouping options so that every format groups the same way.
+type diagram struct {
+	Clusters []cluster
+	// Nodes are drawn outside of any cluster.
+	Nodes []diagramNode
+	Edges []diagramEdge
+	// Names give every node a short identifier, n0, n1 and so on, for
+	// formats whose identifiers cannot hold the characters of IDs.
+	Names map[string]string
+}
+
+type cluster struct {
+	ID    string
+	Label string
+	Nodes []diagramNode
+}
+
+type diagramNode struct {
+	ID    string
+	Label string
+	Style style.Style
+	// Group is set for the nodes standing for a collapsed group, which
+	// every format draws with a shape of its own.
+	Group bool
+}
+
+type diagramEdge struct {
+	From  string
+	To    string
+	Label string
+}
+
+func newDiagram(graph *dependency.Graph, options *Options) *diagram {
+	var grouping = graph.Group(options.groupBy())
+	var result = diagram{Names: map[string]string{}}
+	var group *dependency.Group
+	var e *dependency.Entity
+	for _, group = range grouping.Groups {
+		if options.collapse() {
+			result.Nodes = append(result.Nodes, diagramNode{
+				ID:    group.ID,
+				Label: groupLabel(group),
+				Group: true,
+			})
+			continue
+		}
+		var c = cluster{ID: group.ID, Label: group.Value}
+		for _, e = range group.Members {
+			c.Nodes = append(c.Nodes, entityNode(e, options.styles()))
+		}
+		result.Clusters = append(result.Clusters, c)
+	}
+	for _, e = range grouping.Ungrouped {
+		result.Nodes = append(result.Nodes, entityNode(e, options.styles()))
+	}
+	if options.collapse() {
+		var edge dependency.GroupEdge
+		for _, edge = range grouping.Edges {
+			result.Edges = append(result.Edges, diagramEdge{edge.From, edge.To, groupEdgeLabel(edge)})
+		}
+	} else {
+		var edge dependency.Edge
+		for _, edge = range graph.Edges() {
+			result.Edges = append(result.Edges, diagramEdge{edge.From, edge.To, string(edge.Kind)})
+		}
+	}
+	var c cluster
+	var node diagramNode
+	for _, c = range result.Clusters {
+		for _, node = range c.Nodes {
+			result.Names[node.ID] = fmt.Sprintf("n%d", len(result.Names))
+		}
+	}
+	for _, node = range result.Nodes {
+		result.Names[node.ID] = fmt.Sprintf("n%d", len(result.Names))
+	}
+	return &result
+}
+
+func entityNode(e *dependency.Entity, styles *style.Registry) diagramNode {
+	return diagramNode{ID: e.ID, Label: nodeLabel(e), Style: styles.Style(e)}
+}
+
+func groupLabel(group *dependency.Group) string {
+	if len(group.Members) == 1 {
+		return fmt.Sprintf("%s (1 object)", group.Value)
+	}
+	return fmt.Sprintf("%s (%d objects)", group.Value, len(group.Members))
+}
+
+// groupEdgeLabel lists the kinds of the edges an aggregated edge stands for,
+// and how many there are when there is more than one.
+func groupEdgeLabel(edge dependency.GroupEdge) string {
+	var kinds = []string{}
+	var kind dependency.EdgeKind
+	for _, kind = range edge.Kinds {
+		kinds = append(kinds, string(kind))
+	}
+	if edge.Count == 1 {
+		return kinds[0]
+	}
+	return fmt.Sprintf("%s (%d)", strings.Join(kinds, ", "), edge.Count)
+}
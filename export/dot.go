@@ -0,0 +1,66 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+	"github.com/gkawamoto/k8s-visualizer/style"
+)
+
+var dotShapes = map[style.Shape]string{
+	style.ShapeDot:          "circle",
+	style.ShapeDiamond:      "diamond",
+	style.ShapeSquare:       "box",
+	style.ShapeTriangle:     "triangle",
+	style.ShapeTriangleDown: "invtriangle",
+	style.ShapeHexagon:      "hexagon",
+	style.ShapeStar:         "star",
+}
+
+func writeDOT(w *bufio.Writer, graph *dependency.Graph, options *Options) error {
+	fmt.Fprintf(w, "digraph %s {\n", dotQuote(options.title()))
+	fmt.Fprintf(w, "\trankdir=LR;\n")
+	fmt.Fprintf(w, "\tnode [fontname=\"Verdana\", fontsize=10];\n")
+	fmt.Fprintf(w, "\tedge [fontname=\"Verdana\", fontsize=8];\n")
+	var d = newDiagram(graph, options)
+	var c cluster
+	var node diagramNode
+	for _, c = range d.Clusters {
+		fmt.Fprintf(w, "\tsubgraph %s {\n", dotQuote("cluster_"+c.ID))
+		fmt.Fprintf(w, "\t\tlabel=%s;\n", dotQuote(c.Label))
+		for _, node = range c.Nodes {
+			writeDOTNode(w, "\t\t", node)
+		}
+		fmt.Fprintf(w, "\t}\n")
+	}
+	for _, node = range d.Nodes {
+		writeDOTNode(w, "\t", node)
+	}
+	var edge diagramEdge
+	for _, edge = range d.Edges {
+		fmt.Fprintf(w, "\t%s -> %s [label=%s];\n", dotQuote(edge.From), dotQuote(edge.To), dotQuote(edge.Label))
+	}
+	fmt.Fprintf(w, "}\n")
+	return nil
+}
+
+func writeDOTNode(w *bufio.Writer, indent string, node diagramNode) {
+	if node.Group {
+		fmt.Fprintf(w, "%s%s [label=%s, shape=folder];\n", indent, dotQuote(node.ID), dotQuote(node.Label))
+		return
+	}
+	var s = node.Style
+	var attributes = fmt.Sprintf("style=filled, fillcolor=%s, color=%s, fontcolor=%s", dotQuote(s.Color), dotQuote(s.Color), dotQuote(s.TextColor()))
+	if s.Kind.Placeholder {
+		attributes = fmt.Sprintf("style=dashed, color=%s", dotQuote(s.Color))
+	}
+	fmt.Fprintf(w, "%s%s [label=%s, shape=%s, %s];\n", indent, dotQuote(node.ID), dotQuote(node.Label), dotShapes[s.Kind.Shape], attributes)
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
@@ -0,0 +1,156 @@
+// Package export writes a dependency graph in the formats of other tools:
+// diagram languages, to embed the graph in documents without the window, and
+// graph exchange formats keeping every attribute, for analysis tools.
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+	"github.com/gkawamoto/k8s-visualizer/placement"
+	"github.com/gkawamoto/k8s-visualizer/style"
+)
+
+// Format is the name of an output format.
+type Format string
+
+const (
+	// FormatDOT is the Graphviz DOT language.
+	FormatDOT Format = "dot"
+	// FormatMermaid is a Mermaid flowchart.
+	FormatMermaid Format = "mermaid"
+	// FormatPlantUML is a PlantUML deployment diagram.
+	FormatPlantUML Format = "plantuml"
+	// FormatGraphML is GraphML, as read by yEd and Gephi.
+	FormatGraphML Format = "graphml"
+	// FormatGEXF is GEXF 1.2, the native format of Gephi.
+	FormatGEXF Format = "gexf"
+	// FormatJGF is the JSON Graph Format, version 2.
+	FormatJGF Format = "jgf"
+	// FormatHTML is a standalone page showing the graph as the window does,
+	// with everything it needs inlined so that it opens offline. It cannot
+	// focus on the neighborhood of an object.
+	FormatHTML Format = "html"
+)
+
+// Options configures an export. A nil *Options uses the defaults.
+type Options struct {
+	// Title names the diagram, when the format has a place for it.
+	Title string
+	// GroupBy is the key diagrams group nodes by, as taken by
+	// dependency.Graph.Group. The default is dependency.GroupByNamespace.
+	GroupBy string
+	// Collapse draws every group of a diagram as a single node, with one
+	// edge standing for all the edges between two groups.
+	Collapse bool
+	// Layout is how the html format places nodes when the page opens, the
+	// page's default when empty. The other formats leave placement to the
+	// tools reading them.
+	Layout placement.Layout
+	// Styles gives the shapes and colors of the nodes, and their icons in
+	// the html format. Nil draws with the default styles.
+	Styles *style.Registry
+}
+
+func (o *Options) styles() *style.Registry {
+	if o == nil {
+		return nil
+	}
+	return o.Styles
+}
+
+func (o *Options) title() string {
+	if o == nil {
+		return ""
+	}
+	return o.Title
+}
+
+func (o *Options) groupBy() string {
+	if o == nil || o.GroupBy == "" {
+		return dependency.GroupByNamespace
+	}
+	return o.GroupBy
+}
+
+func (o *Options) collapse() bool {
+	return o != nil && o.Collapse
+}
+
+func (o *Options) layout() placement.Layout {
+	if o == nil {
+		return ""
+	}
+	return o.Layout
+}
+
+type writerFunc func(w *bufio.Writer, graph *dependency.Graph, options *Options) error
+
+var writers = map[Format]writerFunc{
+	FormatDOT:      writeDOT,
+	FormatMermaid:  writeMermaid,
+	FormatPlantUML: writePlantUML,
+	FormatGraphML:  writeGraphML,
+	FormatGEXF:     writeGEXF,
+	FormatJGF:      writeJGF,
+	FormatHTML:     writeHTML,
+}
+
+// Formats returns the names of the supported formats, sorted.
+func Formats() []string {
+	var result = []string{}
+	var format Format
+	for format = range writers {
+		result = append(result, string(format))
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Write writes graph to out in the given format.
+func Write(out io.Writer, graph *dependency.Graph, format Format, options *Options) error {
+	var writer writerFunc
+	var ok bool
+	writer, ok = writers[format]
+	if !ok {
+		return fmt.Errorf("export: unknown format %q, expected one of %s", format, strings.Join(Formats(), ", "))
+	}
+	if !diagramFormats[format] && (options.groupBy() != dependency.GroupByNamespace || options.collapse()) {
+		return fmt.Errorf("export: %s: only the diagram formats, %s, group nodes", format, strings.Join(diagramFormatNames(), ", "))
+	}
+	var w = bufio.NewWriter(out)
+	var err = writer(w, graph, options)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		return fmt.Errorf("export: %s: %s", format, err)
+	}
+	return nil
+}
+
+// namespaceName returns the namespace of e, "default" when it has none.
+func namespaceName(e *dependency.Entity) string {
+	if e.Metadata.Namespace == "" {
+		return "default"
+	}
+	return e.Metadata.Namespace
+}
+
+func sortedKeys(m map[string]string) []string {
+	var result = []string{}
+	var key string
+	for key = range m {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func nodeLabel(e *dependency.Entity) string {
+	return fmt.Sprintf("%s (%s)", e.DisplayName(), e.Kind)
+}
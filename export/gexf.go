@@ -0,0 +1,137 @@
+package export
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+)
+
+type gexf struct {
+	XMLName xml.Name  `xml:"gexf"`
+	XMLNS   string    `xml:"xmlns,attr"`
+	Version string    `xml:"version,attr"`
+	Meta    gexfMeta  `xml:"meta"`
+	Graph   gexfGraph `xml:"graph"`
+}
+
+type gexfMeta struct {
+	Creator     string `xml:"creator"`
+	Description string `xml:"description,omitempty"`
+}
+
+type gexfGraph struct {
+	DefaultEdgeType string           `xml:"defaultedgetype,attr"`
+	Mode            string           `xml:"mode,attr"`
+	Attributes      []gexfAttributes `xml:"attributes"`
+	Nodes           []gexfNode       `xml:"nodes>node"`
+	Edges           []gexfEdge       `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfNode struct {
+	ID        string         `xml:"id,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfEdge struct {
+	ID        string         `xml:"id,attr"`
+	Source    string         `xml:"source,attr"`
+	Target    string         `xml:"target,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// gexfNodeAttributes are the attributes every node may have. Labels get one
+// more attribute each, titled "label:" and the label key. Ports are a
+// liststring, whose values GEXF separates with "|".
+var gexfNodeAttributes = []gexfAttribute{
+	{"kind", "kind", "string"},
+	{"namespace", "namespace", "string"},
+	{"name", "name", "string"},
+	{"ports", "ports", "liststring"},
+	{"file", "file", "string"},
+	{"line", "line", "integer"},
+}
+
+var gexfEdgeAttributes = []gexfAttribute{
+	{"type", "type", "string"},
+	{"port", "port", "string"},
+}
+
+func writeGEXF(w *bufio.Writer, graph *dependency.Graph, options *Options) error {
+	var doc = gexf{
+		XMLNS:   "http://www.gexf.net/1.2draft",
+		Version: "1.2",
+		Meta:    gexfMeta{"k8s-visualizer", options.title()},
+		Graph:   gexfGraph{DefaultEdgeType: "directed", Mode: "static"},
+	}
+	var nodeAttributes = gexfAttributes{"node", append([]gexfAttribute{}, gexfNodeAttributes...)}
+	var labelAttributes = map[string]string{}
+	var index int
+	var key string
+	for index, key = range sortedLabelKeys(graph) {
+		labelAttributes[key] = fmt.Sprintf("label%d", index)
+		nodeAttributes.Attributes = append(nodeAttributes.Attributes, gexfAttribute{labelAttributes[key], "label:" + key, "string"})
+	}
+	doc.Graph.Attributes = []gexfAttributes{nodeAttributes, {"edge", gexfEdgeAttributes}}
+	var e *dependency.Entity
+	for _, e = range graph.Nodes() {
+		var node = gexfNode{ID: e.ID, Label: nodeLabel(e)}
+		node.AttValues = appendGEXFAttValue(node.AttValues, "kind", e.Kind)
+		node.AttValues = appendGEXFAttValue(node.AttValues, "namespace", namespaceName(e))
+		node.AttValues = appendGEXFAttValue(node.AttValues, "name", e.DisplayName())
+		node.AttValues = appendGEXFAttValue(node.AttValues, "ports", joinPorts(entityPorts(e), "|"))
+		node.AttValues = appendGEXFAttValue(node.AttValues, "file", e.Source.File)
+		if e.Source.Line != 0 {
+			node.AttValues = appendGEXFAttValue(node.AttValues, "line", strconv.Itoa(e.Source.Line))
+		}
+		for _, key = range sortedKeys(e.Metadata.Labels) {
+			node.AttValues = appendGEXFAttValue(node.AttValues, labelAttributes[key], e.Metadata.Labels[key])
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+	}
+	var edge dependency.Edge
+	for index, edge = range graph.Edges() {
+		var obj = gexfEdge{ID: fmt.Sprintf("e%d", index), Source: edge.From, Target: edge.To, Label: string(edge.Kind)}
+		obj.AttValues = appendGEXFAttValue(obj.AttValues, "type", string(edge.Kind))
+		obj.AttValues = appendGEXFAttValue(obj.AttValues, "port", edgePort(graph, edge))
+		doc.Graph.Edges = append(doc.Graph.Edges, obj)
+	}
+	return writeXML(w, &doc)
+}
+
+func appendGEXFAttValue(values []gexfAttValue, id, value string) []gexfAttValue {
+	if value == "" {
+		return values
+	}
+	return append(values, gexfAttValue{id, value})
+}
+
+func joinPorts(ports []port, separator string) string {
+	var result = []string{}
+	var p port
+	for _, p = range ports {
+		result = append(result, p.String())
+	}
+	return strings.Join(result, separator)
+}
@@ -0,0 +1,186 @@
+package export
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+)
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Data        []graphMLData `xml:"data"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID    string        `xml:"id,attr"`
+	Data  []graphMLData `xml:"data"`
+	Ports []graphMLPort `xml:"port"`
+}
+
+type graphMLPort struct {
+	Name string        `xml:"name,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID         string        `xml:"id,attr"`
+	Source     string        `xml:"source,attr"`
+	Target     string        `xml:"target,attr"`
+	TargetPort string        `xml:"targetport,attr,omitempty"`
+	Data       []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// graphMLKeys are the attributes every node, port and edge may have. Labels
+// get one more key each, named "label:" and the label key. The ports of a
+// node are GraphML ports, which the edges of Ingress backends point at.
+var graphMLKeys = []graphMLKey{
+	{"title", "graph", "title", "string"},
+	{"label", "node", "label", "string"},
+	{"kind", "node", "kind", "string"},
+	{"namespace", "node", "namespace", "string"},
+	{"name", "node", "name", "string"},
+	{"file", "node", "file", "string"},
+	{"line", "node", "line", "int"},
+	{"portName", "port", "name", "string"},
+	{"portNumber", "port", "number", "int"},
+	{"portProtocol", "port", "protocol", "string"},
+	{"type", "edge", "type", "string"},
+	{"port", "edge", "port", "string"},
+}
+
+func writeGraphML(w *bufio.Writer, graph *dependency.Graph, options *Options) error {
+	var doc = graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys:  append([]graphMLKey{}, graphMLKeys...),
+		Graph: graphMLGraph{ID: "G", EdgeDefault: "directed"},
+	}
+	doc.Graph.Data = appendGraphMLData(nil, "title", options.title())
+	var labelKeys = map[string]string{}
+	var index int
+	var key string
+	for index, key = range sortedLabelKeys(graph) {
+		labelKeys[key] = fmt.Sprintf("label%d", index)
+		doc.Keys = append(doc.Keys, graphMLKey{labelKeys[key], "node", "label:" + key, "string"})
+	}
+	var ports = map[string][]port{}
+	var e *dependency.Entity
+	for _, e = range graph.Nodes() {
+		var node = graphMLNode{ID: e.ID}
+		ports[e.ID] = entityPorts(e)
+		node.Data = appendGraphMLData(node.Data, "label", nodeLabel(e))
+		node.Data = appendGraphMLData(node.Data, "kind", e.Kind)
+		node.Data = appendGraphMLData(node.Data, "namespace", namespaceName(e))
+		node.Data = appendGraphMLData(node.Data, "name", e.DisplayName())
+		node.Data = appendGraphMLData(node.Data, "file", e.Source.File)
+		if e.Source.Line != 0 {
+			node.Data = appendGraphMLData(node.Data, "line", strconv.Itoa(e.Source.Line))
+		}
+		for _, key = range sortedKeys(e.Metadata.Labels) {
+			node.Data = appendGraphMLData(node.Data, labelKeys[key], e.Metadata.Labels[key])
+		}
+		node.Ports = graphMLPorts(ports[e.ID])
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+	}
+	var edge dependency.Edge
+	for index, edge = range graph.Edges() {
+		var obj = graphMLEdge{ID: fmt.Sprintf("e%d", index), Source: edge.From, Target: edge.To}
+		var target = edgePort(graph, edge)
+		obj.TargetPort = graphMLTargetPort(ports[edge.To], target)
+		obj.Data = appendGraphMLData(obj.Data, "type", string(edge.Kind))
+		obj.Data = appendGraphMLData(obj.Data, "port", target)
+		doc.Graph.Edges = append(doc.Graph.Edges, obj)
+	}
+	return writeXML(w, &doc)
+}
+
+// graphMLPorts returns ports as GraphML ports. Ports whose name is taken are
+// left out, since names must be unique within a node.
+func graphMLPorts(ports []port) []graphMLPort {
+	var result []graphMLPort
+	var seen = map[string]bool{}
+	var p port
+	for _, p = range ports {
+		var name = graphMLPortName(p)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		var obj = graphMLPort{Name: name}
+		obj.Data = appendGraphMLData(obj.Data, "portName", p.Name)
+		obj.Data = appendGraphMLData(obj.Data, "portNumber", strconv.Itoa(p.Port))
+		obj.Data = appendGraphMLData(obj.Data, "portProtocol", p.Protocol)
+		result = append(result, obj)
+	}
+	return result
+}
+
+// graphMLPortName returns the GraphML name of p: its port name or, for an
+// unnamed port, its number and protocol.
+func graphMLPortName(p port) string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return fmt.Sprintf("%d-%s", p.Port, p.Protocol)
+}
+
+// graphMLTargetPort returns the GraphML name of the first of ports that
+// target names by number or by name, or "" when none does.
+func graphMLTargetPort(ports []port, target string) string {
+	var p port
+	for _, p = range ports {
+		if target != "" && (target == p.Name || target == strconv.Itoa(p.Port)) {
+			return graphMLPortName(p)
+		}
+	}
+	return ""
+}
+
+// appendGraphMLData appends a data element unless value is empty, since
+// GraphML has no way to tell an empty string from a missing value.
+func appendGraphMLData(data []graphMLData, key, value string) []graphMLData {
+	if value == "" {
+		return data
+	}
+	return append(data, graphMLData{key, value})
+}
+
+func writeXML(w *bufio.Writer, doc interface{}) error {
+	var err error
+	_, err = w.WriteString(xml.Header)
+	if err != nil {
+		return err
+	}
+	var encoder = xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(doc)
+	if err != nil {
+		return err
+	}
+	_, err = w.WriteString("\n")
+	return err
+}
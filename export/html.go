@@ -0,0 +1,328 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/rakyll/statik/fs"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+	"github.com/gkawamoto/k8s-visualizer/nsplot"
+	// The page and its scripts are embedded by statik.
+	_ "github.com/gkawamoto/k8s-visualizer/statik"
+	"github.com/gkawamoto/k8s-visualizer/ui"
+)
+
+// htmlBridge is placed before the scripts of the page. The page uses it in
+// place of the connection to Go: the scripts Go evaluated when the page was
+// ready are replayed, and calls are answered from the results recorded at
+// export time.
+//
+// A report shows the whole graph: the ranks and groups Go gave for it are
+// recorded, and focusing, which would need the neighborhoods of every entity
+// at every depth, is not available, so its controls are hidden. Only
+// filtering is worked out by the bridge, since its query and selector are
+// typed by the user: bridge.filter must select the same entities as
+// dependency.Filter and parse selectors as dependency.ParseSelector does,
+// which TestBridgeSelectors checks.
+const htmlBridge = `<script>
+var exported = %s;
+var bridge = {
+    send: function(message) {
+        message = JSON.parse(message);
+        setTimeout(function() { bridge.receive(message); }, 0);
+    },
+    receive: function(message) {
+        if (message.id) {
+            var response = {id: message.id};
+            try {
+                if (message.method === 'details' && exported.details.hasOwnProperty(message.args[0])) {
+                    response.result = exported.details[message.args[0]];
+                } else if (message.method === 'filter') {
+                    response.result = bridge.filter(message.args[0]);
+                } else if (message.method === 'groups' && exported.groups.hasOwnProperty(message.args[0])) {
+                    response.result = exported.groups[message.args[0]];
+                } else if (message.method === 'groups') {
+                    response.error = 'grouping by ' + message.args[0] + ' is not available in an exported report';
+                } else if (message.method === 'ranks') {
+                    response.result = exported.ranks;
+                } else if (message.method === 'pin' || message.method === 'unpin') {
+                    // A report has nowhere to save positions, they are
+                    // kept until it is closed.
+                } else if (message.method === 'neighborhood' && message.args[0] === null) {
+                    emit('neighborhood', null);
+                } else {
+                    response.error = message.method + ' is not available in an exported report';
+                }
+            } catch (err) {
+                response.error = err.message;
+            }
+            rpc.settle(response);
+        } else if (message.method === 'ready') {
+            document.title = exported.title;
+            exported.scripts.forEach(function(script) {
+                (0, eval)(script);
+            });
+            var style = document.createElement('style');
+            style.textContent = '.focus { display: none; }';
+            document.head.appendChild(style);
+        }
+    },
+    filter: function(request) {
+        var selector = bridge.parseSelector(request.selector);
+        var query = request.query.toLowerCase().replace(/\s/g, '');
+        var result = {matches: [], kinds: [], namespaces: []};
+        exported.ids.forEach(function(id) {
+            var e = exported.details[id];
+            var namespace = e.namespace || 'default';
+            if (result.kinds.indexOf(e.kind) < 0) {
+                result.kinds.push(e.kind);
+            }
+            if (result.namespaces.indexOf(namespace) < 0) {
+                result.namespaces.push(namespace);
+            }
+            if (request.kinds.length > 0 && request.kinds.indexOf(e.kind) < 0 ||
+                request.namespaces.length > 0 && request.namespaces.indexOf(namespace) < 0) {
+                return;
+            }
+            var selected = bridge.matches(selector, e.labels || {});
+            var name = e.name.toLowerCase();
+            var position = 0;
+            for (var i = 0; selected && i < query.length; i++) {
+                position = name.indexOf(query[i], position) + 1;
+                selected = position > 0;
+            }
+            if (selected) {
+                result.matches.push(id);
+            }
+        });
+        result.kinds.sort();
+        result.namespaces.sort();
+        return result;
+    },
+    // matches tells whether labels meet every requirement of selector.
+    matches: function(selector, labels) {
+        return selector.every(function(r) {
+            var present = labels.hasOwnProperty(r.key);
+            switch (r.operator) {
+            case 'in': return present && r.values.indexOf(labels[r.key]) >= 0;
+            case 'notin': return !present || r.values.indexOf(labels[r.key]) < 0;
+            case 'exists': return present;
+            }
+            return !present;
+        });
+    },
+    // parseSelector returns the requirements of a selector, with = and !=
+    // read as in and notin with a single value.
+    parseSelector: function(text) {
+        var tokens = text.match(/==|!=|[=!(),]|[^\s=!(),]+/g) || [];
+        var position = 0;
+        var next = function() {
+            return position < tokens.length ? tokens[position++] : '';
+        };
+        var word = function(token) {
+            return token !== '' && !/^(==|!=|[=!(),])$/.test(token);
+        };
+        var fail = function(message) {
+            throw new Error('invalid selector "' + text + '": ' + message);
+        };
+        var result = [];
+        while (tokens.length > 0) {
+            var token = next();
+            var r = {key: token, operator: 'exists', values: []};
+            if (token === '!') {
+                r = {key: next(), operator: '!', values: []};
+            }
+            if (!word(r.key)) {
+                fail('expected a label key, found "' + r.key + '"');
+            }
+            var plain = r.operator === 'exists';
+            token = next();
+            if (plain && (token === '=' || token === '==' || token === '!=')) {
+                r.operator = token === '!=' ? 'notin' : 'in';
+                r.values.push(word(tokens[position] || '') ? next() : '');
+                token = next();
+            } else if (plain && (token === 'in' || token === 'notin')) {
+                r.operator = token;
+                if (next() !== '(') {
+                    fail('expected "("');
+                }
+                for (token = next(); token !== ')'; token = next()) {
+                    if (word(token)) {
+                        r.values.push(token);
+                        token = next();
+                    }
+                    if (token === ')') {
+                        break;
+                    }
+                    if (token !== ',') {
+                        fail('expected "," or ")", found "' + token + '"');
+                    }
+                }
+                if (r.values.length === 0) {
+                    fail('expected at least one value in parentheses');
+                }
+                token = next();
+            }
+            result.push(r);
+            if (token === '') {
+                break;
+            }
+            if (token !== ',') {
+                fail('expected "," after ' + r.key + ', found "' + token + '"');
+            }
+        }
+        return result;
+    }
+};
+</script>
+`
+
+type htmlData struct {
+	Title   string                 `json:"title"`
+	Scripts []string               `json:"scripts"`
+	Details map[string]interface{} `json:"details"`
+	// IDs are the IDs of the entities in the order of the graph, which
+	// filter results follow.
+	IDs   []string    `json:"ids"`
+	Ranks interface{} `json:"ranks"`
+	// Groups are the groups by each of htmlGroupKeys.
+	Groups map[string]interface{} `json:"groups"`
+}
+
+// htmlGroupKeys are the keys a report can group by: the namespace and the
+// labels Kubernetes recommends for applications.
+var htmlGroupKeys = []string{
+	dependency.GroupByNamespace,
+	"app.kubernetes.io/name",
+	"app.kubernetes.io/instance",
+	"app.kubernetes.io/component",
+	"app.kubernetes.io/part-of",
+}
+
+// writeHTML writes public/index.html with its scripts, styles and the graph
+// inlined, so that the page works offline and without the Go side.
+func writeHTML(w *bufio.Writer, graph *dependency.Graph, options *Options) error {
+	var data htmlData
+	var err error
+	data, err = recordPage(graph, options)
+	if err != nil {
+		return err
+	}
+	var sfs http.FileSystem
+	sfs, err = fs.New()
+	if err != nil {
+		return fmt.Errorf("html: %s", err)
+	}
+	var page, script, style string
+	page, err = readAsset(sfs, "/index.html")
+	if err != nil {
+		return err
+	}
+	script, err = readAsset(sfs, "/vis.min.js")
+	if err != nil {
+		return err
+	}
+	style, err = readAsset(sfs, "/vis-network.min.css")
+	if err != nil {
+		return err
+	}
+	var content []byte
+	content, err = json.Marshal(&data)
+	if err != nil {
+		return fmt.Errorf("html: json: marshal: %s", err)
+	}
+	// The inlined assets cannot contain a closing tag of their own element,
+	// which would end it early.
+	script = strings.Replace(script, "</script", `<\/script`, -1)
+	style = strings.Replace(style, "</style", `<\/style`, -1)
+	page, err = replaceOnce(page, `<script type="text/javascript" src="vis.min.js"></script>`,
+		"<script type=\"text/javascript\">\n"+script+"\n</script>")
+	if err != nil {
+		return err
+	}
+	page, err = replaceOnce(page, `<link href="vis-network.min.css" rel="stylesheet" type="text/css"/>`,
+		"<style>\n"+style+"\n</style>")
+	if err != nil {
+		return err
+	}
+	page, err = replaceOnce(page, "\n<script>\n", "\n"+fmt.Sprintf(htmlBridge, content)+"<script>\n")
+	if err != nil {
+		return err
+	}
+	_, err = w.WriteString(page)
+	return err
+}
+
+// recordPage shows graph in a recorder, as it would be shown in a window
+// opening with the layout and styles of options, and asks for the details of
+// every entity and for the ranks and groups of the graph.
+func recordPage(graph *dependency.Graph, options *Options) (htmlData, error) {
+	var result = htmlData{Details: map[string]interface{}{}, Groups: map[string]interface{}{}}
+	var window = ui.NewRecorder(nil)
+	var plot = nsplot.NewPlotFromGraph(graph, options.title())
+	plot.SetLayout(options.layout())
+	plot.SetStyles(options.styles())
+	var err error
+	_, err = plot.Attach(window)
+	if err != nil {
+		return result, fmt.Errorf("html: %s", err)
+	}
+	err = window.Send("ready", nil)
+	if err != nil {
+		return result, fmt.Errorf("html: %s", err)
+	}
+	var e *dependency.Entity
+	for _, e = range graph.Nodes() {
+		result.IDs = append(result.IDs, e.ID)
+		result.Details[e.ID], err = window.Call("details", e.ID)
+		if err != nil {
+			return result, fmt.Errorf("html: %s", err)
+		}
+	}
+	result.Ranks, err = window.Call("ranks")
+	if err != nil {
+		return result, fmt.Errorf("html: %s", err)
+	}
+	var key string
+	for _, key = range htmlGroupKeys {
+		result.Groups[key], err = window.Call("groups", key)
+		if err != nil {
+			return result, fmt.Errorf("html: %s", err)
+		}
+	}
+	result.Title, result.Scripts, err = window.Recording()
+	if err != nil {
+		return result, fmt.Errorf("html: %s", err)
+	}
+	return result, nil
+}
+
+func readAsset(sfs http.FileSystem, name string) (string, error) {
+	var file http.File
+	var err error
+	file, err = sfs.Open(name)
+	if err != nil {
+		return "", fmt.Errorf("html: %s: %s", name, err)
+	}
+	defer file.Close()
+	var content []byte
+	content, err = ioutil.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("html: %s: %s", name, err)
+	}
+	return string(content), nil
+}
+
+// replaceOnce replaces the first old in s, failing when the template no
+// longer contains it.
+func replaceOnce(s, old, new string) (string, error) {
+	if !strings.Contains(s, old) {
+		return "", fmt.Errorf("html: the page has no %q", old)
+	}
+	return strings.Replace(s, old, new, 1), nil
+}
@@ -0,0 +1,73 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestBridgeSelectors runs the selectors of testdata/selectors.json through
+// the bridge of exported reports, which must read and match them as
+// dependency.ParseSelector does. It needs node to run the bridge, and fails
+// without it rather than let the two parsers drift apart unnoticed.
+func TestBridgeSelectors(t *testing.T) {
+	var node, err = exec.LookPath("node")
+	if err != nil {
+		t.Fatalf("node is needed to run the bridge of exported reports: %s", err)
+	}
+	var cases []byte
+	cases, err = ioutil.ReadFile("../dependency/testdata/selectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var script = fmt.Sprintf(htmlBridge, "{}")
+	script = strings.TrimPrefix(strings.TrimSpace(script), "<script>")
+	script = strings.TrimSuffix(script, "</script>")
+	script += `
+var results = ` + string(cases) + `.map(function(c) {
+    try {
+        return {matches: bridge.matches(bridge.parseSelector(c.selector), c.labels), error: false};
+    } catch (err) {
+        return {matches: false, error: true};
+    }
+});
+console.log(JSON.stringify(results));
+`
+	var path = filepath.Join(t.TempDir(), "bridge.js")
+	err = ioutil.WriteFile(path, []byte(script), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output []byte
+	output, err = exec.Command(node, path).Output()
+	if err != nil {
+		t.Fatalf("node: %s", err)
+	}
+	var want, got []struct {
+		Selector string `json:"selector"`
+		Matches  bool   `json:"matches"`
+		Error    bool   `json:"error"`
+	}
+	err = json.Unmarshal(cases, &want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = json.Unmarshal(output, &got)
+	if err != nil {
+		t.Fatalf("node: %s: %s", err, output)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d results, want %d", len(got), len(want))
+	}
+	var index int
+	for index = range want {
+		if got[index].Error != want[index].Error || got[index].Matches != want[index].Matches {
+			t.Errorf("selector %q: got matches=%t error=%t, want matches=%t error=%t", want[index].Selector,
+				got[index].Matches, got[index].Error, want[index].Matches, want[index].Error)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+)
+
+// jgf is a document of the JSON Graph Format, version 2. See
+// https://jsongraphformat.info.
+type jgf struct {
+	Graph jgfGraph `json:"graph"`
+}
+
+type jgfGraph struct {
+	ID       string             `json:"id,omitempty"`
+	Label    string             `json:"label,omitempty"`
+	Directed bool               `json:"directed"`
+	Type     string             `json:"type"`
+	Nodes    map[string]jgfNode `json:"nodes"`
+	Edges    []jgfEdge          `json:"edges"`
+}
+
+type jgfNode struct {
+	Label    string          `json:"label"`
+	Metadata jgfNodeMetadata `json:"metadata"`
+}
+
+type jgfNodeMetadata struct {
+	Kind        string            `json:"kind"`
+	Namespace   string            `json:"namespace"`
+	Name        string            `json:"name"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Ports       []port            `json:"ports,omitempty"`
+	File        string            `json:"file,omitempty"`
+	Line        int               `json:"line,omitempty"`
+}
+
+type jgfEdge struct {
+	Source   string          `json:"source"`
+	Target   string          `json:"target"`
+	Relation string          `json:"relation"`
+	Directed bool            `json:"directed"`
+	Metadata jgfEdgeMetadata `json:"metadata"`
+}
+
+type jgfEdgeMetadata struct {
+	Port string `json:"port,omitempty"`
+}
+
+func writeJGF(w *bufio.Writer, graph *dependency.Graph, options *Options) error {
+	var doc = jgf{jgfGraph{
+		Label:    options.title(),
+		Directed: true,
+		Type:     "k8s-visualizer",
+		Nodes:    map[string]jgfNode{},
+		Edges:    []jgfEdge{},
+	}}
+	var e *dependency.Entity
+	for _, e = range graph.Nodes() {
+		doc.Graph.Nodes[e.ID] = jgfNode{nodeLabel(e), jgfNodeMetadata{
+			Kind:        e.Kind,
+			Namespace:   namespaceName(e),
+			Name:        e.DisplayName(),
+			Labels:      e.Metadata.Labels,
+			Annotations: e.Metadata.Annotations,
+			Ports:       entityPorts(e),
+			File:        e.Source.File,
+			Line:        e.Source.Line,
+		}}
+	}
+	var edge dependency.Edge
+	for _, edge = range graph.Edges() {
+		doc.Graph.Edges = append(doc.Graph.Edges, jgfEdge{
+			Source:   edge.From,
+			Target:   edge.To,
+			Relation: string(edge.Kind),
+			Directed: true,
+			Metadata: jgfEdgeMetadata{edgePort(graph, edge)},
+		})
+	}
+	var encoder = json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(&doc)
+}
@@ -0,0 +1,71 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+	"github.com/gkawamoto/k8s-visualizer/style"
+)
+
+// mermaidShapes holds the brackets around a node label for each shape.
+// Mermaid has no triangles or stars, so trapezoids pointing up and down
+// stand for triangles and stadiums for stars.
+var mermaidShapes = map[style.Shape][2]string{
+	style.ShapeDot:          {"((", "))"},
+	style.ShapeDiamond:      {"{", "}"},
+	style.ShapeSquare:       {"[", "]"},
+	style.ShapeTriangle:     {"[/", `\]`},
+	style.ShapeTriangleDown: {`[\`, "/]"},
+	style.ShapeHexagon:      {"{{", "}}"},
+	style.ShapeStar:         {"([", "])"},
+}
+
+func writeMermaid(w *bufio.Writer, graph *dependency.Graph, options *Options) error {
+	var d = newDiagram(graph, options)
+	var title = options.title()
+	if title != "" {
+		fmt.Fprintf(w, "---\ntitle: %s\n---\n", mermaidQuote(title))
+	}
+	fmt.Fprintf(w, "flowchart LR\n")
+	var index int
+	var c cluster
+	var node diagramNode
+	for index, c = range d.Clusters {
+		fmt.Fprintf(w, "\tsubgraph g%d [%s]\n", index, mermaidQuote(c.Label))
+		for _, node = range c.Nodes {
+			writeMermaidNode(w, "\t\t", d.Names[node.ID], node)
+		}
+		fmt.Fprintf(w, "\tend\n")
+	}
+	for _, node = range d.Nodes {
+		writeMermaidNode(w, "\t", d.Names[node.ID], node)
+	}
+	var edge diagramEdge
+	for _, edge = range d.Edges {
+		fmt.Fprintf(w, "\t%s -->|%s| %s\n", d.Names[edge.From], mermaidQuote(edge.Label), d.Names[edge.To])
+	}
+	return nil
+}
+
+func writeMermaidNode(w *bufio.Writer, indent string, name string, node diagramNode) {
+	if node.Group {
+		fmt.Fprintf(w, "%s%s[[%s]]\n", indent, name, mermaidQuote(node.Label))
+		return
+	}
+	var s = node.Style
+	var shape = mermaidShapes[s.Kind.Shape]
+	fmt.Fprintf(w, "%s%s%s%s%s\n", indent, name, shape[0], mermaidQuote(node.Label), shape[1])
+	if s.Kind.Placeholder {
+		fmt.Fprintf(w, "%sstyle %s fill:#ffffff,stroke:%s,stroke-dasharray:5 5\n", indent, name, s.Color)
+	} else {
+		fmt.Fprintf(w, "%sstyle %s fill:%s,stroke:%s,color:%s\n", indent, name, s.Color, s.Color, s.TextColor())
+	}
+}
+
+var mermaidEscaper = strings.NewReplacer(`"`, "#quot;", "\n", " ")
+
+func mermaidQuote(s string) string {
+	return `"` + mermaidEscaper.Replace(s) + `"`
+}
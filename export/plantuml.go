@@ -0,0 +1,70 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+	"github.com/gkawamoto/k8s-visualizer/style"
+)
+
+// plantUMLElements holds the deployment diagram element drawn for each shape.
+// PlantUML has no diamonds, triangles or stars there, so the closest
+// elements are used instead.
+var plantUMLElements = map[style.Shape]string{
+	style.ShapeDot:          "usecase",
+	style.ShapeDiamond:      "hexagon",
+	style.ShapeSquare:       "rectangle",
+	style.ShapeTriangle:     "node",
+	style.ShapeTriangleDown: "card",
+	style.ShapeHexagon:      "file",
+	style.ShapeStar:         "component",
+}
+
+func writePlantUML(w *bufio.Writer, graph *dependency.Graph, options *Options) error {
+	var d = newDiagram(graph, options)
+	fmt.Fprintf(w, "@startuml\n")
+	var title = options.title()
+	if title != "" {
+		fmt.Fprintf(w, "title %s\n", plantUMLEscaper.Replace(title))
+	}
+	fmt.Fprintf(w, "left to right direction\n")
+	var c cluster
+	var node diagramNode
+	for _, c = range d.Clusters {
+		fmt.Fprintf(w, "package %s {\n", plantUMLQuote(c.Label))
+		for _, node = range c.Nodes {
+			writePlantUMLNode(w, "\t", d.Names[node.ID], node)
+		}
+		fmt.Fprintf(w, "}\n")
+	}
+	for _, node = range d.Nodes {
+		writePlantUMLNode(w, "", d.Names[node.ID], node)
+	}
+	var edge diagramEdge
+	for _, edge = range d.Edges {
+		fmt.Fprintf(w, "%s --> %s : %s\n", d.Names[edge.From], d.Names[edge.To], plantUMLEscaper.Replace(edge.Label))
+	}
+	fmt.Fprintf(w, "@enduml\n")
+	return nil
+}
+
+func writePlantUMLNode(w *bufio.Writer, indent string, name string, node diagramNode) {
+	if node.Group {
+		fmt.Fprintf(w, "%sfolder %s as %s\n", indent, plantUMLQuote(node.Label), name)
+		return
+	}
+	var s = node.Style
+	var colors = fmt.Sprintf("%s;line:%s;text:%s", s.Color, s.Color, s.TextColor())
+	if s.Kind.Placeholder {
+		colors = fmt.Sprintf("#ffffff;line:%s;line.dashed;text:%s", s.Color, s.Color)
+	}
+	fmt.Fprintf(w, "%s%s %s as %s %s\n", indent, plantUMLElements[s.Kind.Shape], plantUMLQuote(node.Label), name, colors)
+}
+
+var plantUMLEscaper = strings.NewReplacer(`"`, "'", "\n", " ")
+
+func plantUMLQuote(s string) string {
+	return `"` + plantUMLEscaper.Replace(s) + `"`
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+	"github.com/gkawamoto/k8s-visualizer/nsplot"
+)
+
+// focusOptions narrow every command to the neighborhood of one object, for
+// graphs too large to read as a whole.
+type focusOptions struct {
+	target    string
+	depth     int
+	direction string
+}
+
+// resolve returns the focus the options select in graph, or the zero Focus
+// when --focus is not set.
+func (f *focusOptions) resolve(graph *dependency.Graph) (nsplot.Focus, error) {
+	if f.target == "" {
+		return nsplot.Focus{}, nil
+	}
+	var result = nsplot.Focus{Depth: f.depth}
+	var err error
+	if f.depth < 0 {
+		return result, fmt.Errorf("focus: --depth must not be negative")
+	}
+	result.Direction, err = dependency.ParseDirection(f.direction)
+	if err != nil {
+		return result, fmt.Errorf("focus: %s", err)
+	}
+	var e *dependency.Entity
+	e, err = graph.Lookup(f.target)
+	if err != nil {
+		return result, fmt.Errorf("focus: %s", err)
+	}
+	result.Root = e.ID
+	return result, nil
+}
+
+// apply returns the part of graph the options select.
+func (f *focusOptions) apply(graph *dependency.Graph) (*dependency.Graph, error) {
+	var focus, err = f.resolve(graph)
+	if err != nil || focus.Root == "" {
+		return graph, err
+	}
+	return graph.Focus(graph.Entity(focus.Root).Ref(), focus.Depth, focus.Direction)
+}
+
+// focusPlot makes the windows of plot open on the neighborhood the options
+// select.
+func (f *focusOptions) focusPlot(plot *nsplot.Plot) error {
+	var focus nsplot.Focus
+	var err error
+	plot.ReadGraph(func(graph *dependency.Graph) {
+		focus, err = f.resolve(graph)
+	})
+	if err != nil {
+		return err
+	}
+	plot.SetFocus(focus)
+	return nil
+}
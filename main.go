@@ -1,35 +1,103 @@
 package main
 
 import (
+	"fmt"
 	"log"
 
 	"github.com/spf13/cobra"
 
+	"github.com/gkawamoto/k8s-visualizer/dependency"
 	"github.com/gkawamoto/k8s-visualizer/nsplot"
+	"github.com/gkawamoto/k8s-visualizer/placement"
 	_ "github.com/gkawamoto/k8s-visualizer/statik"
+	"github.com/gkawamoto/k8s-visualizer/style"
 	"github.com/gkawamoto/k8s-visualizer/ui"
 )
 
 func main() {
 	var err error
+	var watch bool
+	var options dependency.Options
+	var focus focusOptions
+	var layout string
+	var styleFile string
 	var rootCmd = &cobra.Command{
-		Args: cobra.ExactArgs(1),
+		Use:   "k8s-visualizer <directory>",
+		Short: "Show the dependency graph of the Kubernetes manifests in a directory",
+		Args:  cobra.ExactArgs(1),
+		// Errors are printed by log.Fatal below.
+		SilenceErrors: true,
 		Run: func(cmd *cobra.Command, args []string) {
 			var w *ui.Window
 			w, err = ui.New(nil)
 			if err != nil {
 				log.Fatal("program:", err)
 			}
+			var plot *nsplot.Plot
+			plot, err = nsplot.NewPlot(args[0], &options)
+			if err != nil {
+				log.Fatal(err)
+			}
+			err = focus.focusPlot(plot)
+			if err != nil {
+				log.Fatal(err)
+			}
+			err = layoutPlot(plot, layout)
+			if err != nil {
+				log.Fatal(err)
+			}
+			var styles *style.Registry
+			styles, err = loadStyles(styleFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+			plot.SetStyles(styles)
 			var p *nsplot.PlotHandler
-			p, err = nsplot.NewPlotHandler(w, args[0])
+			p, err = plot.Attach(w)
 			if err != nil {
 				log.Fatal(err)
 			}
+			if watch {
+				err = plot.Watch()
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
 			p.Run()
 		},
 	}
+	rootCmd.Flags().BoolVar(&watch, "watch", false, "reload the graph when manifests in the directory change")
+	rootCmd.PersistentFlags().IntVar(&options.Workers, "workers", 0, "number of manifest files loaded concurrently (default: number of CPUs)")
+	rootCmd.PersistentFlags().StringVar(&options.CacheDir, "cache-dir", "", "directory where parsed manifests are cached between runs")
+	rootCmd.PersistentFlags().StringVar(&focus.target, "focus", "", "only show the objects near this one, given as kind/name or namespace/kind/name")
+	rootCmd.PersistentFlags().IntVar(&focus.depth, "depth", 1, "with --focus, how many edges away from the object to go")
+	rootCmd.PersistentFlags().StringVar(&focus.direction, "direction", "both", "with --focus, which edges to follow: upstream, downstream or both")
+	rootCmd.PersistentFlags().StringVar(&layout, "layout", "", fmt.Sprintf("node placement, %s or %s (default: %s for render, %s in the page, which can switch)", placement.Force, placement.Layered, placement.Layered, placement.Force))
+	rootCmd.PersistentFlags().StringVar(&styleFile, "style", "", "YAML file overriding the icons of kinds and the colors of namespaces")
+	rootCmd.AddCommand(newExportCommand(&options, &focus, &layout, &styleFile))
+	rootCmd.AddCommand(newRenderCommand(&options, &focus, &layout, &styleFile))
+	rootCmd.AddCommand(newServeCommand(&options, &focus, &layout, &styleFile))
 	err = rootCmd.Execute()
 	if err != nil {
 		log.Fatal(err)
 	}
 }
+
+// layoutPlot makes the windows of plot open with the named layout.
+func layoutPlot(plot *nsplot.Plot, name string) error {
+	var layout, err = placement.ParseLayout(name)
+	if err != nil {
+		return err
+	}
+	plot.SetLayout(layout)
+	return nil
+}
+
+// loadStyles reads the style file at path, or returns nil, the default
+// styles, when path is empty.
+func loadStyles(path string) (*style.Registry, error) {
+	if path == "" {
+		return nil, nil
+	}
+	return style.LoadRegistry(path)
+}
@@ -0,0 +1,66 @@
+package nsplot
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+)
+
+// filterRequest is the state of the search bar and filter panel of the page.
+type filterRequest struct {
+	Query      string   `json:"query"`
+	Kinds      []string `json:"kinds"`
+	Namespaces []string `json:"namespaces"`
+	Selector   string   `json:"selector"`
+}
+
+type filterResult struct {
+	// Matches are the IDs of the entities selected.
+	Matches []string `json:"matches"`
+	// Kinds and Namespaces are those of the entities shown, offered as
+	// choices by the filter panel.
+	Kinds      []string `json:"kinds"`
+	Namespaces []string `json:"namespaces"`
+}
+
+// filter selects entities for the page, so that label selectors typed there
+// mean what they mean for Services.
+func (p *PlotHandler) filter(request filterRequest) (*filterResult, error) {
+	var f = dependency.Filter{
+		Query:      request.Query,
+		Kinds:      request.Kinds,
+		Namespaces: request.Namespaces,
+	}
+	var err error
+	f.Selector, err = dependency.ParseSelector(request.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("filter: %s", err)
+	}
+	p.plot.lock.Lock()
+	defer p.plot.lock.Unlock()
+	var result = filterResult{Matches: []string{}}
+	var kinds = map[string]bool{}
+	var namespaces = map[string]bool{}
+	var e *dependency.Entity
+	for _, e = range p.view().Nodes() {
+		kinds[e.Kind] = true
+		namespaces[e.Namespace()] = true
+		if f.Matches(e) {
+			result.Matches = append(result.Matches, e.ID)
+		}
+	}
+	result.Kinds = sortedSet(kinds)
+	result.Namespaces = sortedSet(namespaces)
+	return &result, nil
+}
+
+func sortedSet(set map[string]bool) []string {
+	var result = []string{}
+	var key string
+	for key = range set {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
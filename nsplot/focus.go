@@ -0,0 +1,89 @@
+package nsplot
+
+import (
+	"fmt"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+)
+
+// Focus narrows a window to the neighborhood of one entity: the entities at
+// most Depth edges away from Root following Direction, and the edges between
+// them. The zero Focus shows the whole graph.
+type Focus struct {
+	// Root is the ID of the entity in the middle of the neighborhood.
+	Root      string
+	Depth     int
+	Direction dependency.Direction
+}
+
+// SetFocus sets the neighborhood shown by windows when their page is loaded.
+// Users can then focus elsewhere, or show the whole graph, in each window.
+func (p *Plot) SetFocus(focus Focus) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.focus = focus
+}
+
+// view returns the part of the graph the window shows. A focus whose root was
+// removed by a reload is dropped, and the whole graph is shown again.
+func (p *PlotHandler) view() *dependency.Graph {
+	if p.focus.Root == "" {
+		return p.plot.graph
+	}
+	var root = p.plot.graph.Entity(p.focus.Root)
+	if root != nil {
+		var graph, err = p.plot.graph.Focus(root.Ref(), p.focus.Depth, p.focus.Direction)
+		if err == nil {
+			return graph
+		}
+	}
+	p.focus = Focus{}
+	return p.plot.graph
+}
+
+type neighborhood struct {
+	ID        string `json:"id"`
+	Label     string `json:"label,omitempty"`
+	Depth     int    `json:"depth"`
+	Direction string `json:"direction"`
+}
+
+// showFocus tells the page which neighborhood it shows, or null for the
+// whole graph.
+func (p *PlotHandler) showFocus() error {
+	if p.focus.Root == "" {
+		return p.window.Emit("neighborhood", nil)
+	}
+	return p.window.Emit("neighborhood", neighborhood{
+		ID:        p.focus.Root,
+		Label:     nodeLabel(p.plot.graph.Entity(p.focus.Root)),
+		Depth:     p.focus.Depth,
+		Direction: p.focus.Direction.String(),
+	})
+}
+
+// setNeighborhood is called by the page to focus on the neighborhood of an
+// entity, or with null to show the whole graph again.
+func (p *PlotHandler) setNeighborhood(request *neighborhood) error {
+	var focus Focus
+	if request != nil {
+		var err error
+		focus.Direction, err = dependency.ParseDirection(request.Direction)
+		if err != nil {
+			return fmt.Errorf("neighborhood: %s", err)
+		}
+		if request.Depth < 0 {
+			return fmt.Errorf("neighborhood: depth must not be negative")
+		}
+		focus.Root = request.ID
+		focus.Depth = request.Depth
+	}
+	p.plot.lock.Lock()
+	defer p.plot.lock.Unlock()
+	if focus.Root != "" && p.plot.graph.Entity(focus.Root) == nil {
+		return fmt.Errorf("neighborhood: unknown entity %s", focus.Root)
+	}
+	p.focus = focus
+	p.show()
+	return nil
+}
@@ -0,0 +1,57 @@
+package nsplot
+
+import (
+	"fmt"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+)
+
+type grouping struct {
+	Groups []group     `json:"groups"`
+	Edges  []groupEdge `json:"edges"`
+}
+
+type group struct {
+	ID      string   `json:"id"`
+	Label   string   `json:"label"`
+	Members []string `json:"members"`
+}
+
+type groupEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Count int    `json:"count"`
+}
+
+// groups is called by the page to group the entities shown by namespace, or
+// by the value of the label key, into clusters it can collapse.
+func (p *PlotHandler) groups(key string) (*grouping, error) {
+	if key == "" {
+		return nil, fmt.Errorf("groups: no key")
+	}
+	p.plot.lock.Lock()
+	defer p.plot.lock.Unlock()
+	var g = p.view().Group(key)
+	var result = grouping{Groups: []group{}, Edges: []groupEdge{}}
+	var obj *dependency.Group
+	for _, obj = range g.Groups {
+		var members = []string{}
+		var e *dependency.Entity
+		for _, e = range obj.Members {
+			members = append(members, e.ID)
+		}
+		result.Groups = append(result.Groups, group{obj.ID, groupLabel(obj), members})
+	}
+	var edge dependency.GroupEdge
+	for _, edge = range g.Edges {
+		result.Edges = append(result.Edges, groupEdge{edge.From, edge.To, edge.Count})
+	}
+	return &result, nil
+}
+
+func groupLabel(g *dependency.Group) string {
+	if len(g.Members) == 1 {
+		return fmt.Sprintf("%s (1 object)", g.Value)
+	}
+	return fmt.Sprintf("%s (%d objects)", g.Value, len(g.Members))
+}
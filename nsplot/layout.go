@@ -0,0 +1,30 @@
+package nsplot
+
+import (
+	"github.com/gkawamoto/k8s-visualizer/placement"
+)
+
+// SetLayout sets how windows place nodes when their page is loaded, or the
+// page's default when layout is empty. Users can then switch layouts in each
+// window.
+func (p *Plot) SetLayout(layout placement.Layout) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.layout = layout
+}
+
+// showLayout tells the page which layout to start with.
+func (p *PlotHandler) showLayout() error {
+	if p.plot.layout == "" {
+		return nil
+	}
+	return p.window.Emit("layout", p.plot.layout)
+}
+
+// ranks is called by the page to place the entities shown in tiers, from
+// left to right, when it uses the layered layout.
+func (p *PlotHandler) ranks() map[string]int {
+	p.plot.lock.Lock()
+	defer p.plot.lock.Unlock()
+	return p.view().Ranks()
+}
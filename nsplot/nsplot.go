@@ -4,62 +4,268 @@ import (
 	"fmt"
 	"log"
 	"path/filepath"
+	"sync"
 
 	"github.com/gkawamoto/k8s-visualizer/dependency"
+	"github.com/gkawamoto/k8s-visualizer/placement"
+	"github.com/gkawamoto/k8s-visualizer/style"
 	"github.com/gkawamoto/k8s-visualizer/ui"
 )
 
-// PlotHandler ?
-type PlotHandler struct {
+// Plot is the graph of a directory of manifests, shown in any number of
+// windows.
+type Plot struct {
 	title  string
-	window *ui.Window
 	target string
 	graph  *dependency.Graph
+	focus  Focus
+	layout placement.Layout
+	styles *style.Registry
+	// positions are those of the pinned nodes, keyed by entity ID.
+	positions map[string]position
+	lock      sync.Mutex
+	handlers  map[*ui.Window]*PlotHandler
 }
 
-// NewPlotHandler ?
-func NewPlotHandler(w *ui.Window, target string) (*PlotHandler, error) {
-	var result = &PlotHandler{
-		window: w,
-		target: target,
-	}
+// PlotHandler shows a Plot in one window and keeps the window up to date as
+// the plot changes.
+type PlotHandler struct {
+	plot   *Plot
+	window *ui.Window
+	ready  bool
+	shown  shownSet
+	focus  Focus
+}
+
+// shownSet is the set of nodes and edges currently in the window.
+type shownSet struct {
+	nodes map[string]bool
+	edges map[[2]string]bool
+}
+
+// NewPlot builds the graph of the manifests in target.
+func NewPlot(target string, options *dependency.Options) (*Plot, error) {
 	var absTarget string
 	var err error
 	absTarget, err = filepath.Abs(target)
 	if err != nil {
 		return nil, err
 	}
-	result.title = filepath.Base(absTarget)
-	result.graph, err = dependency.BuildGraph(target)
+	var graph *dependency.Graph
+	graph, err = dependency.BuildGraph(target, options)
 	if err != nil {
 		return nil, err
 	}
+	var result = NewPlotFromGraph(graph, filepath.Base(absTarget))
+	result.target = target
+	// Losing the pinned positions is no reason not to show the graph.
+	result.positions, err = loadPositions(positionsPath(target))
+	if err != nil {
+		log.Println(err)
+	}
+	return result, nil
+}
+
+// NewPlotFromGraph returns a plot of a graph that was already built, shown
+// with the given title. It cannot be watched.
+func NewPlotFromGraph(graph *dependency.Graph, title string) *Plot {
+	return &Plot{
+		title:     title,
+		graph:     graph,
+		positions: map[string]position{},
+		handlers:  map[*ui.Window]*PlotHandler{},
+	}
+}
+
+// ReadGraph calls f with the graph, which is not reloaded until f returns.
+func (p *Plot) ReadGraph(f func(graph *dependency.Graph)) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	f(p.graph)
+}
+
+// Attach shows the plot in w once its page is ready, and keeps it up to date
+// until w is closed.
+func (p *Plot) Attach(w *ui.Window) (*PlotHandler, error) {
+	var result = &PlotHandler{
+		plot:   p,
+		window: w,
+	}
 	w.Register("ready", result.readyHandler)
+	var err = w.Handle("details", result.details)
+	if err != nil {
+		return nil, err
+	}
+	err = w.Handle("filter", result.filter)
+	if err != nil {
+		return nil, err
+	}
+	err = w.Handle("neighborhood", result.setNeighborhood)
+	if err != nil {
+		return nil, err
+	}
+	err = w.Handle("groups", result.groups)
+	if err != nil {
+		return nil, err
+	}
+	err = w.Handle("ranks", result.ranks)
+	if err != nil {
+		return nil, err
+	}
+	err = w.Handle("pin", result.pin)
+	if err != nil {
+		return nil, err
+	}
+	err = w.Handle("unpin", result.unpin)
+	if err != nil {
+		return nil, err
+	}
+	w.OnClose(func() {
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		delete(p.handlers, w)
+	})
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.handlers[w] = result
 	return result, nil
 }
 
 func (p *PlotHandler) readyHandler(data []byte) {
-	var err error
-	var graph *dependency.Graph
-	graph, err = dependency.BuildGraph(p.target)
+	p.plot.lock.Lock()
+	defer p.plot.lock.Unlock()
+	p.ready = true
+	p.window.Reset()
+	p.shown = shownSet{}
+	p.focus = p.plot.focus
+	p.window.SetTitle(p.plot.title)
+	var err = p.showLayout()
+	if err != nil {
+		log.Println(err)
+	}
+	err = p.showPositions()
 	if err != nil {
-		log.Fatal(err)
+		log.Println(err)
 	}
+	p.show()
+}
+
+// show brings the window up to date with the graph, or the part of it in
+// focus, sending only the nodes and edges that changed since the last call.
+func (p *PlotHandler) show() {
+	var shown = shownSet{
+		nodes: map[string]bool{},
+		edges: map[[2]string]bool{},
+	}
+	var graph = p.view()
+	// Warnings are linted on the whole graph, which tells whether the
+	// Services a workload references are defined even out of focus.
+	var styles = p.plot.styles.Styles(p.plot.graph)
 	var e *dependency.Entity
-	for _, e = range graph.Entities() {
-		var name = fmt.Sprintf("%s (%s)", e.Metadata.Name, e.Kind)
-		p.window.AddNode(e.ID, name, ui.KubernetesKindToNodeKind(e.Kind))
+	for _, e = range graph.Nodes() {
+		shown.nodes[e.ID] = true
+		p.window.AddNode(e.ID, nodeLabel(e), nodeStyle(styles[e.ID]))
+	}
+	var graphEdge dependency.Edge
+	for _, graphEdge = range graph.Edges() {
+		shown.edges[[2]string{graphEdge.From, graphEdge.To}] = true
+		p.window.AddEdge(graphEdge.From, graphEdge.To)
+	}
+	var edge [2]string
+	for edge = range p.shown.edges {
+		if !shown.edges[edge] {
+			p.window.RemoveEdge(edge[0], edge[1])
+		}
 	}
-	var from, to int
-	for from, to = range graph.References() {
-		log.Println(from, to)
-		p.window.AddEdge(from, to)
+	var id string
+	for id = range p.shown.nodes {
+		if !shown.nodes[id] {
+			p.window.RemoveNode(id)
+		}
 	}
-	p.window.SetTitle(p.title)
-	p.window.Refresh()
+	p.shown = shown
+	var err = p.window.Refresh()
+	if err != nil {
+		log.Println(err)
+	}
+	err = p.showFocus()
+	if err != nil {
+		log.Println(err)
+	}
+	err = p.showLegend()
+	if err != nil {
+		log.Println(err)
+	}
+	p.showDiagnostics(p.plot.graph.Diagnostics())
+}
+
+// showDiagnostics replaces the problems listed in the window's error panel.
+func (p *PlotHandler) showDiagnostics(diagnostics []dependency.Diagnostic) {
+	var err = p.window.Emit("diagnostics", diagnostics)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+type entityDetails struct {
+	ID          string            `json:"id"`
+	Label       string            `json:"label"`
+	Name        string            `json:"name"`
+	Kind        string            `json:"kind"`
+	Namespace   string            `json:"namespace"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	File        string            `json:"file"`
+	Line        int               `json:"line"`
+	Incoming    []reference       `json:"incoming"`
+	Outgoing    []reference       `json:"outgoing"`
+	YAML        string            `json:"yaml"`
+}
+
+type reference struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+func (p *PlotHandler) details(id string) (*entityDetails, error) {
+	p.plot.lock.Lock()
+	defer p.plot.lock.Unlock()
+	var graph = p.plot.graph
+	var e = graph.Entity(id)
+	if e == nil {
+		return nil, fmt.Errorf("details: unknown entity %s", id)
+	}
+	var result = entityDetails{
+		ID:          e.ID,
+		Label:       nodeLabel(e),
+		Name:        e.DisplayName(),
+		Kind:        e.Kind,
+		Namespace:   e.Metadata.Namespace,
+		Labels:      e.Metadata.Labels,
+		Annotations: e.Metadata.Annotations,
+		File:        e.Source.File,
+		Line:        e.Source.Line,
+		Incoming:    references(graph.Neighbors(e.Ref(), dependency.DirectionIncoming)),
+		Outgoing:    references(graph.Neighbors(e.Ref(), dependency.DirectionOutgoing)),
+		YAML:        e.Raw,
+	}
+	return &result, nil
+}
+
+func references(entities []*dependency.Entity) []reference {
+	var result = []reference{}
+	var e *dependency.Entity
+	for _, e = range entities {
+		result = append(result, reference{e.ID, nodeLabel(e)})
+	}
+	return result
+}
+
+func nodeLabel(e *dependency.Entity) string {
+	return fmt.Sprintf("%s (%s)", e.DisplayName(), e.Kind)
 }
 
-// Run ?
+// Run shows the window until it is closed.
 func (p *PlotHandler) Run() {
 	p.window.Run()
 }
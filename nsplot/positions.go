@@ -0,0 +1,171 @@
+package nsplot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// PositionsFile is the name of the file, next to the manifests, keeping the
+// positions users pinned nodes at.
+const PositionsFile = ".k8s-visualizer-layout.json"
+
+type position struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// positionsData is the content of PositionsFile. Positions are keyed by
+// entity ID, which does not change between runs, and are kept for entities
+// that disappeared in case they come back.
+type positionsData struct {
+	Version   int                 `json:"version"`
+	Positions map[string]position `json:"positions"`
+}
+
+const positionsVersion = 1
+
+// positionsPath returns where the positions of the plot of target are kept:
+// in target when it is a directory, next to it otherwise.
+func positionsPath(target string) string {
+	var info, err = os.Stat(target)
+	if err == nil && !info.IsDir() {
+		return filepath.Join(filepath.Dir(target), PositionsFile)
+	}
+	return filepath.Join(target, PositionsFile)
+}
+
+// loadPositions reads the positions saved at path. A missing file holds no
+// positions.
+func loadPositions(path string) (map[string]position, error) {
+	var result = map[string]position{}
+	var content, err = ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return result, nil
+	}
+	if err != nil {
+		return result, fmt.Errorf("positions: %s", err)
+	}
+	var data positionsData
+	err = json.Unmarshal(content, &data)
+	if err != nil {
+		return result, fmt.Errorf("positions: %s: %s", path, err)
+	}
+	if data.Version != positionsVersion {
+		return result, fmt.Errorf("positions: %s: unknown version %d", path, data.Version)
+	}
+	if data.Positions != nil {
+		result = data.Positions
+	}
+	return result, nil
+}
+
+// writePositions replaces the file at path, through a temporary file so that
+// it is never left half written.
+func writePositions(path string, positions map[string]position) error {
+	var content, err = json.MarshalIndent(&positionsData{positionsVersion, positions}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("positions: json: marshal: %s", err)
+	}
+	var file *os.File
+	file, err = ioutil.TempFile(filepath.Dir(path), PositionsFile+".*")
+	if err != nil {
+		return fmt.Errorf("positions: %s", err)
+	}
+	_, err = file.Write(append(content, '\n'))
+	if err == nil {
+		// Temporary files are only readable by their owner.
+		err = file.Chmod(0644)
+	}
+	if err == nil {
+		err = file.Close()
+	} else {
+		file.Close()
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return fmt.Errorf("positions: %s", err)
+	}
+	return nil
+}
+
+// showPositions sends the pinned positions to the page, which places the
+// nodes it has or gets later at them.
+func (p *PlotHandler) showPositions() error {
+	return p.window.Emit("positions", p.plot.positions)
+}
+
+// pin is called by the page when the user dropped nodes, to keep them where
+// they were dropped.
+func (p *PlotHandler) pin(positions map[string]position) error {
+	p.plot.lock.Lock()
+	defer p.plot.lock.Unlock()
+	if p.plot.target == "" {
+		return fmt.Errorf("pin: positions are only saved for a directory of manifests")
+	}
+	var id string
+	for id = range positions {
+		if p.plot.graph.Entity(id) == nil {
+			return fmt.Errorf("pin: unknown entity %s", id)
+		}
+	}
+	var updated = p.plot.copyPositions()
+	var pos position
+	for id, pos = range positions {
+		updated[id] = pos
+	}
+	return p.plot.savePositions(updated)
+}
+
+// unpin is called by the page to let physics place nodes again.
+func (p *PlotHandler) unpin(ids []string) error {
+	p.plot.lock.Lock()
+	defer p.plot.lock.Unlock()
+	if p.plot.target == "" {
+		return fmt.Errorf("unpin: positions are only saved for a directory of manifests")
+	}
+	var updated = p.plot.copyPositions()
+	var id string
+	for _, id = range ids {
+		delete(updated, id)
+	}
+	return p.plot.savePositions(updated)
+}
+
+func (p *Plot) copyPositions() map[string]position {
+	var result = map[string]position{}
+	var id string
+	var pos position
+	for id, pos = range p.positions {
+		result[id] = pos
+	}
+	return result
+}
+
+// savePositions writes positions and, once they are saved, makes them the
+// positions of the plot and sends them to every window, so that all of them
+// show the nodes where they are pinned. Nothing changes when writing fails.
+func (p *Plot) savePositions(positions map[string]position) error {
+	var err = writePositions(positionsPath(p.target), positions)
+	if err != nil {
+		return err
+	}
+	p.positions = positions
+	var handler *PlotHandler
+	for _, handler = range p.handlers {
+		if !handler.ready {
+			continue
+		}
+		err = handler.showPositions()
+		if err != nil {
+			log.Println(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package nsplot
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPinKeepsPositionsWhenSavingFails(t *testing.T) {
+	var dir = t.TempDir()
+	var err = ioutil.WriteFile(filepath.Join(dir, "web.yaml"), []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var plot *Plot
+	plot, err = NewPlot(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var handler = &PlotHandler{plot: plot}
+	var id = plot.graph.Nodes()[0].ID
+
+	// A directory in the way of the positions file makes saving fail.
+	var blocker = filepath.Join(positionsPath(dir), "blocker")
+	err = os.MkdirAll(blocker, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = handler.pin(map[string]position{id: {1, 2}})
+	if err == nil {
+		t.Fatalf("pin succeeded with a directory in place of %s", positionsPath(dir))
+	}
+	if len(plot.positions) != 0 {
+		t.Fatalf("positions changed to %v although they were not saved", plot.positions)
+	}
+
+	err = os.RemoveAll(positionsPath(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = handler.pin(map[string]position{id: {1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved map[string]position
+	saved, err = loadPositions(positionsPath(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plot.positions[id] != (position{1, 2}) || saved[id] != (position{1, 2}) {
+		t.Fatalf("got %v in the plot and %v saved, want %s at 1,2", plot.positions, saved, id)
+	}
+}
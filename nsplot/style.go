@@ -0,0 +1,25 @@
+package nsplot
+
+import (
+	"github.com/gkawamoto/k8s-visualizer/style"
+	"github.com/gkawamoto/k8s-visualizer/ui"
+)
+
+// SetStyles sets how windows draw entities. A nil registry draws with the
+// defaults.
+func (p *Plot) SetStyles(styles *style.Registry) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.styles = styles
+}
+
+// nodeStyle returns how the window draws an entity of style s.
+func nodeStyle(s style.Style) ui.NodeStyle {
+	return ui.NodeStyle{Shape: "image", Image: s.IconURI(), Title: s.Tooltip()}
+}
+
+// showLegend tells the page what the icons and colors of the entities shown
+// stand for.
+func (p *PlotHandler) showLegend() error {
+	return p.window.Emit("legend", p.plot.styles.Legend(p.view()))
+}
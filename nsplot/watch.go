@@ -0,0 +1,121 @@
+package nsplot
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+)
+
+// watchDelay is how long Watch waits for more changes before reloading, so
+// that editors saving several files at once cause a single reload.
+const watchDelay = 200 * time.Millisecond
+
+// Watch reloads the graph whenever a file under the target directory changes
+// and sends the differences to every window.
+func (p *Plot) Watch() error {
+	if p.target == "" {
+		return fmt.Errorf("watch: the plot was not built from a directory")
+	}
+	var watcher *fsnotify.Watcher
+	var err error
+	watcher, err = fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("watch: %s", err)
+	}
+	err = addWatches(watcher, p.target)
+	if err != nil {
+		watcher.Close()
+		return fmt.Errorf("watch: %s", err)
+	}
+	go p.watch(watcher)
+	return nil
+}
+
+func (p *Plot) watch(watcher *fsnotify.Watcher) {
+	defer watcher.Close()
+	var changed = map[string]bool{}
+	var timer = time.NewTimer(watchDelay)
+	timer.Stop()
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if isPositionsFile(event.Name) {
+				// Pinning nodes writes it, which changes no manifest.
+				continue
+			}
+			if event.Op&fsnotify.Create != 0 {
+				var info os.FileInfo
+				var err error
+				info, err = os.Stat(event.Name)
+				if err == nil && info.IsDir() {
+					err = addWatches(watcher, event.Name)
+					if err != nil {
+						log.Println(fmt.Errorf("watch: %s", err))
+					}
+				}
+			}
+			changed[event.Name] = true
+			timer.Reset(watchDelay)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Println(fmt.Errorf("watch: %s", err))
+		case <-timer.C:
+			var paths = []string{}
+			var path string
+			for path = range changed {
+				paths = append(paths, path)
+			}
+			changed = map[string]bool{}
+			p.reload(paths)
+		}
+	}
+}
+
+func (p *Plot) reload(paths []string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	var err = p.graph.Reload(paths...)
+	var handler *PlotHandler
+	for _, handler = range p.handlers {
+		if !handler.ready {
+			continue
+		}
+		if err != nil {
+			handler.showDiagnostics([]dependency.Diagnostic{{Severity: dependency.SeverityError, Message: err.Error()}})
+			continue
+		}
+		handler.show()
+	}
+}
+
+// isPositionsFile tells whether path is a PositionsFile or one of the
+// temporary files it is written to.
+func isPositionsFile(path string) bool {
+	var name = filepath.Base(path)
+	return name == PositionsFile || strings.HasPrefix(name, PositionsFile+".")
+}
+
+// addWatches watches root and every directory below it.
+func addWatches(watcher *fsnotify.Watcher, root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		return watcher.Add(path)
+	})
+}
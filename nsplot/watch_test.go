@@ -0,0 +1,24 @@
+package nsplot
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIsPositionsFile(t *testing.T) {
+	var cases = map[string]bool{
+		PositionsFile: true,
+		filepath.Join("manifests", PositionsFile):           true,
+		filepath.Join("manifests", PositionsFile+".123456"): true,
+		filepath.Join("manifests", "app.yaml"):              false,
+		filepath.Join("manifests", "layout.json"):           false,
+		filepath.Join(PositionsFile, "app.yaml"):            false,
+	}
+	var path string
+	var want bool
+	for path, want = range cases {
+		if isPositionsFile(path) != want {
+			t.Errorf("isPositionsFile(%q) = %t, want %t", path, !want, want)
+		}
+	}
+}
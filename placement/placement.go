@@ -0,0 +1,31 @@
+// Package placement names the algorithms placing the nodes of a graph, so
+// that the images drawn by package render and the window showing the page
+// can be asked for the same layout without depending on each other.
+package placement
+
+import (
+	"fmt"
+)
+
+// Layout is the name of an algorithm placing the nodes of a graph.
+type Layout string
+
+const (
+	// Layered places nodes in columns from left to right, in the direction
+	// of the edges, so that Ingresses come before the Services they route
+	// to and Services before the workloads they select.
+	Layered Layout = "layered"
+	// Force places nodes with a force-directed simulation, like the window
+	// does by default.
+	Force Layout = "force"
+)
+
+// ParseLayout checks the name of a layout. The empty name stands for the
+// default layout of whatever draws the graph.
+func ParseLayout(s string) (Layout, error) {
+	var layout = Layout(s)
+	if layout != "" && layout != Layered && layout != Force {
+		return "", fmt.Errorf("unknown layout %q, expected %s or %s", s, Layered, Force)
+	}
+	return layout, nil
+}
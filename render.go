@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+	"github.com/gkawamoto/k8s-visualizer/placement"
+	"github.com/gkawamoto/k8s-visualizer/render"
+	"github.com/gkawamoto/k8s-visualizer/style"
+)
+
+func newRenderCommand(options *dependency.Options, focus *focusOptions, layout *string, styleFile *string) *cobra.Command {
+	var format string
+	var output string
+	var cmd = &cobra.Command{
+		Use:   "render <directory>",
+		Short: "Draw the graph as an SVG or PNG image, without a window",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("format") && strings.EqualFold(filepath.Ext(output), ".png") {
+				format = "png"
+			}
+			var write func(out io.Writer, graph *dependency.Graph, options *render.Options) error
+			switch format {
+			case "svg":
+				write = render.SVG
+			case "png":
+				write = render.PNG
+			default:
+				return fmt.Errorf("render: unknown format %q, expected svg or png", format)
+			}
+			var imageLayout, err = placement.ParseLayout(*layout)
+			if err != nil {
+				return err
+			}
+			var styles *style.Registry
+			styles, err = loadStyles(*styleFile)
+			if err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true
+			var graph *dependency.Graph
+			graph, err = loadGraph(args[0], options, focus)
+			if err != nil {
+				return err
+			}
+			return writeOutput(output, func(out io.Writer) error {
+				return write(out, graph, &render.Options{
+					Layout: imageLayout,
+					Title:  targetTitle(args[0]),
+					Styles: styles,
+				})
+			})
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", "svg", "image format, svg or png (default: png when the output file ends in .png)")
+	cmd.Flags().StringVarP(&output, "output", "o", "-", "file to write to, or - for the standard output")
+	return cmd
+}
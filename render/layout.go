@@ -0,0 +1,229 @@
+package render
+
+import (
+	"math"
+	"sort"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+	"github.com/gkawamoto/k8s-visualizer/placement"
+)
+
+const (
+	layerGap   = 80.0
+	rowGap     = 70.0
+	forceSpace = 150.0
+
+	forceGravity = 0.05
+	// forceCutoff is the distance, in multiples of the ideal edge length,
+	// beyond which nodes stop repelling each other. Without it the nodes
+	// without edges are pushed far from the rest.
+	forceCutoff = 3.0
+)
+
+type point struct {
+	X float64
+	Y float64
+}
+
+// place returns the center of every node of graph, keyed by entity ID, with
+// the smallest coordinates at the origin.
+func place(graph *dependency.Graph, layout placement.Layout, widths map[string]float64) map[string]point {
+	if layout == placement.Force {
+		return normalize(force(graph))
+	}
+	return normalize(layered(graph, widths))
+}
+
+// layered puts every node in the layer of its rank, see
+// dependency.Graph.Ranks, then orders each layer by the barycenter of the
+// neighbors in the previous and next layers to reduce crossings.
+func layered(graph *dependency.Graph, widths map[string]float64) map[string]point {
+	var layers = orderLayers(graph, assignLayers(graph))
+	var result = map[string]point{}
+	var x float64
+	var previousWidth float64
+	var index int
+	var layer []string
+	for index, layer = range layers {
+		var width float64
+		var id string
+		for _, id = range layer {
+			width = math.Max(width, widths[id])
+		}
+		if index > 0 {
+			x += previousWidth/2 + layerGap + width/2
+		}
+		previousWidth = width
+		var y = -float64(len(layer)-1) * rowGap / 2
+		for _, id = range layer {
+			result[id] = point{x, y}
+			y += rowGap
+		}
+	}
+	return result
+}
+
+// assignLayers groups the nodes by their rank in graph, each layer in the
+// order of graph.Nodes.
+func assignLayers(graph *dependency.Graph) [][]string {
+	var ranks = graph.Ranks()
+	var layers = [][]string{}
+	var e *dependency.Entity
+	for _, e = range graph.Nodes() {
+		for len(layers) <= ranks[e.ID] {
+			layers = append(layers, []string{})
+		}
+		layers[ranks[e.ID]] = append(layers[ranks[e.ID]], e.ID)
+	}
+	return layers
+}
+
+// barycenterSweeps is how many times orderLayers goes down and up the
+// layers. A few sweeps remove most crossings; more rarely help.
+const barycenterSweeps = 4
+
+// orderLayers reorders every layer by the mean position of each node's
+// neighbors in the layer before it, then in the layer after it.
+func orderLayers(graph *dependency.Graph, layers [][]string) [][]string {
+	var position = map[string]float64{}
+	var layer []string
+	var index int
+	var id string
+	for _, layer = range layers {
+		for index, id = range layer {
+			position[id] = float64(index)
+		}
+	}
+	var sweep int
+	for sweep = 0; sweep < barycenterSweeps; sweep++ {
+		for index = 1; index < len(layers); index++ {
+			sortByBarycenter(graph, layers[index], layers[index-1], position)
+		}
+		for index = len(layers) - 2; index >= 0; index-- {
+			sortByBarycenter(graph, layers[index], layers[index+1], position)
+		}
+	}
+	return layers
+}
+
+func sortByBarycenter(graph *dependency.Graph, layer []string, fixed []string, position map[string]float64) {
+	var inFixed = map[string]bool{}
+	var id string
+	for _, id = range fixed {
+		inFixed[id] = true
+	}
+	var barycenter = map[string]float64{}
+	for _, id = range layer {
+		var sum float64
+		var count int
+		var edge dependency.Edge
+		for _, edge = range graph.EdgesOf(id, dependency.DirectionBoth) {
+			var other = edge.To
+			if other == id {
+				other = edge.From
+			}
+			if inFixed[other] {
+				sum += position[other]
+				count++
+			}
+		}
+		barycenter[id] = position[id]
+		if count > 0 {
+			barycenter[id] = sum / float64(count)
+		}
+	}
+	sort.SliceStable(layer, func(i, j int) bool {
+		return barycenter[layer[i]] < barycenter[layer[j]]
+	})
+	var index int
+	for index, id = range layer {
+		position[id] = float64(index)
+	}
+}
+
+// force runs a Fruchterman-Reingold simulation starting from the nodes
+// evenly spread on a circle, so that the result is the same on every run.
+func force(graph *dependency.Graph) map[string]point {
+	var nodes = graph.Nodes()
+	var result = map[string]point{}
+	if len(nodes) == 0 {
+		return result
+	}
+	var size = math.Sqrt(float64(len(nodes))) * forceSpace
+	var k = size / math.Sqrt(float64(len(nodes)))
+	var index int
+	var e *dependency.Entity
+	for index, e = range nodes {
+		var angle = 2 * math.Pi * float64(index) / float64(len(nodes))
+		result[e.ID] = point{size / 2 * math.Cos(angle), size / 2 * math.Sin(angle)}
+	}
+	// The repulsion between every pair of nodes makes an iteration
+	// quadratic, so large graphs get fewer iterations.
+	var iterations = int(math.Max(30, math.Min(300, 2e7/float64(len(nodes)*len(nodes)))))
+	var temperature = size / 10
+	var iteration int
+	for iteration = 0; iteration < iterations; iteration++ {
+		var displacement = map[string]point{}
+		var i, j int
+		for i = range nodes {
+			for j = i + 1; j < len(nodes); j++ {
+				var delta, distance = difference(result[nodes[i].ID], result[nodes[j].ID])
+				if distance > forceCutoff*k {
+					continue
+				}
+				var strength = k * k / distance
+				displacement[nodes[i].ID] = add(displacement[nodes[i].ID], scale(delta, strength/distance))
+				displacement[nodes[j].ID] = add(displacement[nodes[j].ID], scale(delta, -strength/distance))
+			}
+		}
+		var edge dependency.Edge
+		for _, edge = range graph.Edges() {
+			var delta, distance = difference(result[edge.From], result[edge.To])
+			var strength = distance * distance / k
+			displacement[edge.From] = add(displacement[edge.From], scale(delta, -strength/distance))
+			displacement[edge.To] = add(displacement[edge.To], scale(delta, strength/distance))
+		}
+		for _, e = range nodes {
+			// A weak pull to the center gathers the connected
+			// components.
+			var d = add(displacement[e.ID], scale(result[e.ID], -forceGravity))
+			var length = math.Max(math.Hypot(d.X, d.Y), 0.01)
+			result[e.ID] = add(result[e.ID], scale(d, math.Min(length, temperature)/length))
+		}
+		temperature *= 1 - 1/float64(iterations)
+	}
+	return result
+}
+
+// difference returns a-b and its length, never zero so that it can be divided
+// by.
+func difference(a, b point) (point, float64) {
+	var delta = point{a.X - b.X, a.Y - b.Y}
+	var distance = math.Hypot(delta.X, delta.Y)
+	if distance < 0.01 {
+		return point{0.01, 0}, 0.01
+	}
+	return delta, distance
+}
+
+func add(a, b point) point {
+	return point{a.X + b.X, a.Y + b.Y}
+}
+
+func scale(p point, factor float64) point {
+	return point{p.X * factor, p.Y * factor}
+}
+
+// normalize moves the points so that the smallest coordinates are zero.
+func normalize(points map[string]point) map[string]point {
+	var origin = point{math.Inf(1), math.Inf(1)}
+	var p point
+	for _, p = range points {
+		origin = point{math.Min(origin.X, p.X), math.Min(origin.Y, p.Y)}
+	}
+	var id string
+	for id, p = range points {
+		points[id] = point{p.X - origin.X, p.Y - origin.Y}
+	}
+	return points
+}
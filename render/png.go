@@ -0,0 +1,105 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+func writePNG(out io.Writer, d *drawing) error {
+	var img = image.NewRGBA(image.Rect(0, 0, int(math.Ceil(d.Width)), int(math.Ceil(d.Height))))
+	draw.Draw(img, img.Bounds(), image.NewUniform(parseColor(backgroundColor)), image.Point{}, draw.Src)
+	if d.Title != "" {
+		drawText(img, d.Title, point{margin, margin}, false)
+	}
+	var edge drawnEdge
+	for _, edge = range d.Edges {
+		fillPolygon(img, thickLine(edge.From, edge.To, edgeWidth), parseColor(edgeColor))
+		fillPolygon(img, arrowHead(edge), parseColor(edgeColor))
+		drawText(img, edge.Label, point{(edge.From.X + edge.To.X) / 2, (edge.From.Y+edge.To.Y)/2 - 3}, true)
+	}
+	var node drawnNode
+	for _, node = range d.Nodes {
+		// The border is the shape in the border color, covered by the
+		// shape shrunk by the border width in the fill color.
+		fillPolygon(img, shape(node.Shape, node.Center, 1+borderWidth/2/nodeSize), parseColor(node.Border))
+		fillPolygon(img, shape(node.Shape, node.Center, 1-borderWidth/2/nodeSize), parseColor(node.Fill))
+		drawText(img, node.Label, point{node.Center.X, node.Center.Y + labelOffset}, true)
+	}
+	var err = png.Encode(out, img)
+	if err != nil {
+		return fmt.Errorf("render: png: %s", err)
+	}
+	return nil
+}
+
+// fillPolygon fills points with c, rasterizing only the polygon's bounding
+// box so that drawing many small shapes on a large image stays cheap.
+func fillPolygon(img *image.RGBA, points []point, c color.Color) {
+	var bounds = image.Rectangle{}
+	var index int
+	var p point
+	for index, p = range points {
+		var r = image.Rect(int(math.Floor(p.X)), int(math.Floor(p.Y)), int(math.Ceil(p.X))+1, int(math.Ceil(p.Y))+1)
+		if index == 0 {
+			bounds = r
+		} else {
+			bounds = bounds.Union(r)
+		}
+	}
+	bounds = bounds.Intersect(img.Bounds())
+	if bounds.Empty() {
+		return
+	}
+	var r = vector.NewRasterizer(bounds.Dx(), bounds.Dy())
+	r.DrawOp = draw.Over
+	var origin = point{float64(bounds.Min.X), float64(bounds.Min.Y)}
+	for index, p = range points {
+		if index == 0 {
+			r.MoveTo(float32(p.X-origin.X), float32(p.Y-origin.Y))
+		} else {
+			r.LineTo(float32(p.X-origin.X), float32(p.Y-origin.Y))
+		}
+	}
+	r.ClosePath()
+	r.Draw(img, bounds, image.NewUniform(c), image.Point{})
+}
+
+// thickLine returns the rectangle covering a line of the given width.
+func thickLine(from, to point, width float64) []point {
+	var delta, distance = difference(to, from)
+	var normal = scale(point{-delta.Y, delta.X}, width/2/distance)
+	return []point{add(from, normal), add(to, normal), add(to, scale(normal, -1)), add(from, scale(normal, -1))}
+}
+
+// drawText writes text with its baseline at p, centered on p when centered
+// is set.
+func drawText(img *image.RGBA, text string, p point, centered bool) {
+	var drawer = font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(parseColor(textColor)),
+		Face: basicfont.Face7x13,
+	}
+	var x = fixed.I(int(p.X))
+	if centered {
+		x -= drawer.MeasureString(text) / 2
+	}
+	drawer.Dot = fixed.Point26_6{X: x, Y: fixed.I(int(p.Y))}
+	drawer.DrawString(text)
+}
+
+// parseColor parses a "#RRGGBB" color.
+func parseColor(s string) color.Color {
+	var c = color.RGBA{A: 0xff}
+	fmt.Sscanf(s, "#%02x%02x%02x", &c.R, &c.G, &c.B)
+	return c
+}
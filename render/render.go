@@ -0,0 +1,240 @@
+// Package render draws a dependency graph as an SVG or PNG image, without a
+// window, with the labels the window uses and the shapes and colors of the
+// style registry.
+package render
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+	"github.com/gkawamoto/k8s-visualizer/placement"
+	"github.com/gkawamoto/k8s-visualizer/style"
+)
+
+// Options configures a rendering. A nil *Options uses the defaults.
+type Options struct {
+	// Layout places the nodes. The default is placement.Layered.
+	Layout placement.Layout
+	// Title is written above the graph when set.
+	Title string
+	// Styles gives the shapes and colors of the nodes. Nil draws with
+	// the default styles.
+	Styles *style.Registry
+}
+
+func (o *Options) layout() placement.Layout {
+	if o == nil || o.Layout == "" {
+		return placement.Layered
+	}
+	return o.Layout
+}
+
+func (o *Options) styles() *style.Registry {
+	if o == nil {
+		return nil
+	}
+	return o.Styles
+}
+
+func (o *Options) title() string {
+	if o == nil {
+		return ""
+	}
+	return o.Title
+}
+
+const (
+	nodeSize        = 18.0
+	margin          = 40.0
+	titleHeight     = 30.0
+	fontSize        = 12.0
+	edgeFontSize    = 10.0
+	charWidth       = 7.0
+	labelOffset     = nodeSize + 16
+	arrowLength     = 10.0
+	arrowWidth      = 8.0
+	edgeWidth       = 1.5
+	borderWidth     = 2.0
+	edgeColor       = "#848484"
+	textColor       = "#343434"
+	backgroundColor = "#FFFFFF"
+)
+
+// drawing is a graph with every node placed, ready to be written in any image
+// format.
+type drawing struct {
+	Width  float64
+	Height float64
+	Title  string
+	Nodes  []drawnNode
+	Edges  []drawnEdge
+}
+
+type drawnNode struct {
+	ID     string
+	Label  string
+	Shape  style.Shape
+	Fill   string
+	Border string
+	Center point
+}
+
+// drawnEdge goes from the border of one node to the border of the other, To
+// being the tip of the arrow.
+type drawnEdge struct {
+	From  point
+	To    point
+	Label string
+}
+
+// SVG writes graph to out as an SVG image.
+func SVG(out io.Writer, graph *dependency.Graph, options *Options) error {
+	var d, err = newDrawing(graph, options)
+	if err != nil {
+		return err
+	}
+	return writeSVG(out, d)
+}
+
+// PNG writes graph to out as a PNG image.
+func PNG(out io.Writer, graph *dependency.Graph, options *Options) error {
+	var d, err = newDrawing(graph, options)
+	if err != nil {
+		return err
+	}
+	return writePNG(out, d)
+}
+
+// newDrawing lays graph out as options ask.
+func newDrawing(graph *dependency.Graph, options *Options) (*drawing, error) {
+	var layout, err = placement.ParseLayout(string(options.layout()))
+	if err != nil {
+		return nil, fmt.Errorf("render: %s", err)
+	}
+	var widths = map[string]float64{}
+	var e *dependency.Entity
+	for _, e = range graph.Nodes() {
+		widths[e.ID] = math.Max(2*nodeSize, textWidth(nodeLabel(e)))
+	}
+	var positions = place(graph, layout, widths)
+	var result = drawing{Title: options.title()}
+	// Bounds of the nodes and their labels, which are centered below them.
+	var low = point{math.Inf(1), math.Inf(1)}
+	var high = point{math.Inf(-1), math.Inf(-1)}
+	for _, e = range graph.Nodes() {
+		var p = positions[e.ID]
+		low = point{math.Min(low.X, p.X-widths[e.ID]/2), math.Min(low.Y, p.Y-nodeSize*1.25)}
+		high = point{math.Max(high.X, p.X+widths[e.ID]/2), math.Max(high.Y, p.Y+labelOffset)}
+	}
+	var offset = point{margin, margin}
+	if result.Title != "" {
+		offset.Y += titleHeight
+	}
+	if len(positions) > 0 {
+		offset = point{offset.X - low.X, offset.Y - low.Y}
+		result.Width = high.X + offset.X + margin
+		result.Height = high.Y + offset.Y + margin
+	}
+	var registry = options.styles()
+	for _, e = range graph.Nodes() {
+		var s = registry.Style(e)
+		var node = drawnNode{e.ID, nodeLabel(e), s.Kind.Shape, s.Color, s.Color, add(positions[e.ID], offset)}
+		if s.Kind.Placeholder {
+			// Placeholders are only outlined, as in the window.
+			node.Fill = backgroundColor
+		}
+		result.Nodes = append(result.Nodes, node)
+	}
+	var centers = map[string]point{}
+	var node drawnNode
+	for _, node = range result.Nodes {
+		centers[node.ID] = node.Center
+	}
+	var edge dependency.Edge
+	for _, edge = range graph.Edges() {
+		var from, to = clip(centers[edge.From], centers[edge.To])
+		result.Edges = append(result.Edges, drawnEdge{from, to, string(edge.Kind)})
+	}
+	result.Width = math.Max(result.Width, textWidth(result.Title)+2*margin)
+	result.Height = math.Max(result.Height, offset.Y+margin)
+	return &result, nil
+}
+
+// clip shortens the segment between two node centers so that it starts and
+// ends at their borders. Nodes too close to each other for that keep the
+// middle half of the segment, so that their edge is still drawn.
+func clip(from, to point) (point, point) {
+	var delta, distance = difference(to, from)
+	var gap = math.Min(nodeSize+4, distance/4)
+	var unit = scale(delta, 1/distance)
+	return add(from, scale(unit, gap)), add(to, scale(unit, -gap))
+}
+
+func textWidth(text string) float64 {
+	return float64(len([]rune(text))) * charWidth
+}
+
+func nodeLabel(e *dependency.Entity) string {
+	return fmt.Sprintf("%s (%s)", e.DisplayName(), e.Kind)
+}
+
+// shape returns the outline of a node of the given shape centered on center,
+// scaled by factor. Dots are approximated by a polygon.
+func shape(s style.Shape, center point, factor float64) []point {
+	var radius = nodeSize * factor
+	switch s {
+	case style.ShapeDot:
+		return regularPolygon(center, radius, 48, 0)
+	case style.ShapeDiamond:
+		return regularPolygon(center, radius*1.25, 4, -90)
+	case style.ShapeSquare:
+		return regularPolygon(center, radius*1.25, 4, 45)
+	case style.ShapeTriangle:
+		return regularPolygon(center, radius*1.2, 3, -90)
+	case style.ShapeHexagon:
+		return regularPolygon(center, radius*1.1, 6, 0)
+	case style.ShapeStar:
+		return star(center, radius*1.25)
+	}
+	return regularPolygon(center, radius*1.2, 3, 90)
+}
+
+// star returns a five-pointed star, its inner points at 40% of the radius.
+func star(center point, radius float64) []point {
+	var result = []point{}
+	var index int
+	for index = 0; index < 10; index++ {
+		var r = radius
+		if index%2 == 1 {
+			r = radius * 0.4
+		}
+		var angle = (-90 + 36*float64(index)) * math.Pi / 180
+		result = append(result, point{center.X + r*math.Cos(angle), center.Y + r*math.Sin(angle)})
+	}
+	return result
+}
+
+func regularPolygon(center point, radius float64, sides int, startDegrees float64) []point {
+	var result = []point{}
+	var index int
+	for index = 0; index < sides; index++ {
+		var angle = (startDegrees + 360*float64(index)/float64(sides)) * math.Pi / 180
+		result = append(result, point{center.X + radius*math.Cos(angle), center.Y + radius*math.Sin(angle)})
+	}
+	return result
+}
+
+// arrowHead returns the triangle at the end of edge.
+func arrowHead(edge drawnEdge) []point {
+	var delta, distance = difference(edge.To, edge.From)
+	var unit = scale(delta, 1/distance)
+	var normal = point{-unit.Y, unit.X}
+	var base = add(edge.To, scale(unit, -arrowLength))
+	return []point{
+		edge.To,
+		add(base, scale(normal, arrowWidth/2)),
+		add(base, scale(normal, -arrowWidth/2)),
+	}
+}
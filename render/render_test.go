@@ -0,0 +1,168 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+	"github.com/gkawamoto/k8s-visualizer/placement"
+)
+
+// shop is a graph of a few related objects: an Ingress, a Service, the
+// Deployment it selects, and the ConfigMap and Service the Deployment uses.
+func shop(t *testing.T) *dependency.Graph {
+	t.Helper()
+	var graph, err = dependency.BuildGraph("../dependency/testdata/shop", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(graph.Nodes()) < 4 || len(graph.Edges()) < 3 {
+		t.Fatalf("got %d nodes and %d edges, want a graph to draw", len(graph.Nodes()), len(graph.Edges()))
+	}
+	return graph
+}
+
+func TestLayeredPlacesRanksInColumns(t *testing.T) {
+	var graph = shop(t)
+	var positions = place(graph, placement.Layered, map[string]float64{})
+	var ranks = graph.Ranks()
+	var a, b *dependency.Entity
+	for _, a = range graph.Nodes() {
+		for _, b = range graph.Nodes() {
+			var pa, pb = positions[a.ID], positions[b.ID]
+			switch {
+			case a == b:
+			case ranks[a.ID] < ranks[b.ID] && pa.X >= pb.X:
+				t.Errorf("%s of rank %d is not left of %s of rank %d", a.ID, ranks[a.ID], b.ID, ranks[b.ID])
+			case ranks[a.ID] == ranks[b.ID] && pa.X != pb.X:
+				t.Errorf("%s and %s have rank %d but are in different columns", a.ID, b.ID, ranks[a.ID])
+			case pa == pb:
+				t.Errorf("%s and %s are both at %v", a.ID, b.ID, pa)
+			}
+		}
+	}
+}
+
+func TestForceIsDeterministic(t *testing.T) {
+	var graph = shop(t)
+	var first = place(graph, placement.Force, nil)
+	var second = place(graph, placement.Force, nil)
+	var e *dependency.Entity
+	for _, e = range graph.Nodes() {
+		var p = first[e.ID]
+		if math.IsNaN(p.X) || math.IsNaN(p.Y) || p.X < 0 || p.Y < 0 {
+			t.Errorf("%s placed at %v", e.ID, p)
+		}
+		if p != second[e.ID] {
+			t.Errorf("%s placed at %v, then at %v", e.ID, p, second[e.ID])
+		}
+	}
+}
+
+func TestClipKeepsShortEdges(t *testing.T) {
+	var tests = [][2]point{
+		{{0, 0}, {200, 0}},
+		{{0, 0}, {0, 30}},
+		{{10, 10}, {10, 10}},
+	}
+	var test [2]point
+	for _, test = range tests {
+		var from, to = clip(test[0], test[1])
+		var _, full = difference(test[1], test[0])
+		var _, clipped = difference(to, from)
+		if clipped <= 0 || clipped > full || math.IsNaN(clipped) {
+			t.Errorf("clip(%v, %v) = %v, %v", test[0], test[1], from, to)
+		}
+	}
+	var from, to = clip(point{0, 0}, point{200, 0})
+	if from.X != nodeSize+4 || to.X != 200-nodeSize-4 {
+		t.Errorf("long edge clipped to %v, %v, want it to start and end at the node borders", from, to)
+	}
+}
+
+type svgDocument struct {
+	Width  float64 `xml:"width,attr"`
+	Height float64 `xml:"height,attr"`
+	Groups []struct {
+		Lines []struct {
+			X1 float64 `xml:"x1,attr"`
+		} `xml:"line"`
+		Nodes []struct {
+			Title string `xml:"title"`
+		} `xml:"g"`
+	} `xml:"g"`
+}
+
+func TestSVG(t *testing.T) {
+	var graph = shop(t)
+	var out bytes.Buffer
+	var err = SVG(&out, graph, &Options{Title: "shop & co"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc svgDocument
+	err = xml.Unmarshal(out.Bytes(), &doc)
+	if err != nil {
+		t.Fatalf("invalid SVG: %s\n%s", err, out.String())
+	}
+	if doc.Width <= 0 || doc.Height <= 0 {
+		t.Errorf("SVG is %vx%v", doc.Width, doc.Height)
+	}
+	var lines, titles = 0, map[string]bool{}
+	var index, node int
+	for index = range doc.Groups {
+		lines += len(doc.Groups[index].Lines)
+		for node = range doc.Groups[index].Nodes {
+			titles[doc.Groups[index].Nodes[node].Title] = true
+		}
+	}
+	if lines != len(graph.Edges()) {
+		t.Errorf("got %d edges drawn, want %d", lines, len(graph.Edges()))
+	}
+	var e *dependency.Entity
+	for _, e = range graph.Nodes() {
+		if !titles[e.ID] {
+			t.Errorf("%s is not drawn", e.ID)
+		}
+	}
+}
+
+func TestPNG(t *testing.T) {
+	var graph = shop(t)
+	var d, err = newDrawing(graph, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	err = PNG(&out, graph, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var img image.Image
+	img, err = png.Decode(&out)
+	if err != nil {
+		t.Fatalf("invalid PNG: %s", err)
+	}
+	var size = img.Bounds().Size()
+	if size.X != int(math.Ceil(d.Width)) || size.Y != int(math.Ceil(d.Height)) {
+		t.Errorf("PNG is %v, want %.0fx%.0f", size, d.Width, d.Height)
+	}
+	var r, g, b, _ = img.At(0, 0).RGBA()
+	if r>>8 != 0xFF || g>>8 != 0xFF || b>>8 != 0xFF {
+		t.Errorf("corner is %v, want the white background", img.At(0, 0))
+	}
+	var node drawnNode
+	for _, node = range d.Nodes {
+		var want = parseColor(node.Fill)
+		var got = img.At(int(node.Center.X), int(node.Center.Y))
+		var gr, gg, gb, _ = got.RGBA()
+		var wr, wg, wb, _ = want.RGBA()
+		if gr != wr || gg != wg || gb != wb {
+			t.Errorf("center of %s is %v, want its fill %s", node.ID, got, node.Fill)
+		}
+	}
+}
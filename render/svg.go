@@ -0,0 +1,56 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/gkawamoto/k8s-visualizer/style"
+)
+
+func writeSVG(out io.Writer, d *drawing) error {
+	var w = bufio.NewWriter(out)
+	fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%.0f\" height=\"%.0f\" viewBox=\"0 0 %.0f %.0f\" font-family=\"Verdana, Arial, sans-serif\">\n", d.Width, d.Height, d.Width, d.Height)
+	fmt.Fprintf(w, "<rect width=\"100%%\" height=\"100%%\" fill=\"%s\"/>\n", backgroundColor)
+	if d.Title != "" {
+		fmt.Fprintf(w, "<text x=\"%.1f\" y=\"%.1f\" font-size=\"16\" font-weight=\"bold\" fill=\"%s\">%s</text>\n", margin, margin, textColor, html.EscapeString(d.Title))
+	}
+	fmt.Fprintf(w, "<g class=\"edges\" stroke=\"%s\" stroke-width=\"%.1f\" fill=\"%s\">\n", edgeColor, edgeWidth, edgeColor)
+	var edge drawnEdge
+	for _, edge = range d.Edges {
+		fmt.Fprintf(w, "<line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\"/>\n", edge.From.X, edge.From.Y, edge.To.X, edge.To.Y)
+		fmt.Fprintf(w, "<polygon points=\"%s\" stroke=\"none\"/>\n", svgPoints(arrowHead(edge)))
+		var middle = scale(add(edge.From, edge.To), 0.5)
+		fmt.Fprintf(w, "<text x=\"%.1f\" y=\"%.1f\" font-size=\"%.0f\" text-anchor=\"middle\" stroke=\"none\">%s</text>\n", middle.X, middle.Y-3, edgeFontSize, html.EscapeString(edge.Label))
+	}
+	fmt.Fprintf(w, "</g>\n")
+	fmt.Fprintf(w, "<g class=\"nodes\" stroke-width=\"%.1f\">\n", borderWidth)
+	var node drawnNode
+	for _, node = range d.Nodes {
+		fmt.Fprintf(w, "<g fill=\"%s\" stroke=\"%s\">\n<title>%s</title>\n", node.Fill, node.Border, html.EscapeString(node.ID))
+		if node.Shape == style.ShapeDot {
+			fmt.Fprintf(w, "<circle cx=\"%.1f\" cy=\"%.1f\" r=\"%.1f\"/>\n", node.Center.X, node.Center.Y, nodeSize)
+		} else {
+			fmt.Fprintf(w, "<polygon points=\"%s\"/>\n", svgPoints(shape(node.Shape, node.Center, 1)))
+		}
+		fmt.Fprintf(w, "<text x=\"%.1f\" y=\"%.1f\" font-size=\"%.0f\" text-anchor=\"middle\" fill=\"%s\" stroke=\"none\">%s</text>\n", node.Center.X, node.Center.Y+labelOffset, fontSize, textColor, html.EscapeString(node.Label))
+		fmt.Fprintf(w, "</g>\n")
+	}
+	fmt.Fprintf(w, "</g>\n</svg>\n")
+	var err = w.Flush()
+	if err != nil {
+		return fmt.Errorf("render: svg: %s", err)
+	}
+	return nil
+}
+
+func svgPoints(points []point) string {
+	var result = []string{}
+	var p point
+	for _, p = range points {
+		result = append(result, fmt.Sprintf("%.1f,%.1f", p.X, p.Y))
+	}
+	return strings.Join(result, " ")
+}
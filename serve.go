@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gkawamoto/k8s-visualizer/api"
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+	"github.com/gkawamoto/k8s-visualizer/nsplot"
+	"github.com/gkawamoto/k8s-visualizer/style"
+	"github.com/gkawamoto/k8s-visualizer/ui"
+)
+
+func newServeCommand(options *dependency.Options, focus *focusOptions, layout *string, styleFile *string) *cobra.Command {
+	var address string
+	var watch bool
+	var cmd = &cobra.Command{
+		Use:   "serve <directory>",
+		Short: "Serve the graph to web browsers, and as JSON under /api/, instead of opening a window",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			var plot *nsplot.Plot
+			var err error
+			plot, err = nsplot.NewPlot(args[0], options)
+			if err != nil {
+				return err
+			}
+			err = focus.focusPlot(plot)
+			if err != nil {
+				return err
+			}
+			err = layoutPlot(plot, *layout)
+			if err != nil {
+				return err
+			}
+			var styles *style.Registry
+			styles, err = loadStyles(*styleFile)
+			if err != nil {
+				return err
+			}
+			plot.SetStyles(styles)
+			var server *ui.Server
+			server, err = ui.NewServer(address, nil)
+			if err != nil {
+				return err
+			}
+			var session = ui.NewSession()
+			server.OnWindow = func(w *ui.Window) {
+				var _, err = plot.Attach(w)
+				if err != nil {
+					log.Println(err)
+					w.Terminate()
+					return
+				}
+				session.Join(w)
+			}
+			server.Handle("/api/", api.NewHandler(plot))
+			if watch {
+				err = plot.Watch()
+				if err != nil {
+					return err
+				}
+			}
+			fmt.Fprintf(os.Stderr, "serving %s on %s\n", args[0], server.URL())
+			return server.Run()
+		},
+	}
+	cmd.Flags().StringVar(&address, "address", "127.0.0.1:8080", "address to listen on; use :8080 to accept connections from other hosts")
+	cmd.Flags().BoolVar(&watch, "watch", false, "reload the graph when manifests in the directory change")
+	return cmd
+}
@@ -0,0 +1,172 @@
+package style
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// config is the content of a style file:
+//
+//	palette: ["#326ce5", "#d9480f", "#2b8a3e"]
+//	namespaces:
+//	  payments: "#c92a2a"
+//	icons: community/icons/svg/resources/labeled
+//	kinds:
+//	  Rollout:
+//	    abbreviation: ro
+//	    shape: square
+//	    icon: icons/rollout.svg
+//
+// Every part is optional and only replaces what it names. Icons is a
+// directory of SVG files named after the abbreviations of the kinds, such as
+// deploy.svg and svc.svg, which is how the Kubernetes icon set names them;
+// kinds without a file there keep their generated icon. The icon of a kind
+// takes precedence over the directory. Paths are relative to the file.
+type config struct {
+	Palette    []string              `yaml:"palette"`
+	Namespaces map[string]string     `yaml:"namespaces"`
+	Icons      string                `yaml:"icons"`
+	Kinds      map[string]kindConfig `yaml:"kinds"`
+}
+
+type kindConfig struct {
+	Abbreviation string `yaml:"abbreviation"`
+	Shape        Shape  `yaml:"shape"`
+	Icon         string `yaml:"icon"`
+}
+
+// LoadRegistry returns a registry with the defaults overridden by the style
+// file at path.
+func LoadRegistry(path string) (*Registry, error) {
+	var content, err = ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("style: %s", err)
+	}
+	var obj config
+	err = yaml.UnmarshalStrict(content, &obj)
+	if err != nil {
+		return nil, fmt.Errorf("style: %s: %s", path, err)
+	}
+	var result = NewRegistry()
+	err = result.apply(&obj, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("style: %s: %s", path, err)
+	}
+	return result, nil
+}
+
+func (r *Registry) apply(obj *config, dir string) error {
+	var err error
+	if len(obj.Palette) > 0 {
+		r.palette = make([]string, len(obj.Palette))
+		var index int
+		var color string
+		for index, color = range obj.Palette {
+			r.palette[index], err = parseColor(color)
+			if err != nil {
+				return fmt.Errorf("palette: %s", err)
+			}
+		}
+	}
+	var name, color string
+	for name, color = range obj.Namespaces {
+		r.namespaces[name], err = parseColor(color)
+		if err != nil {
+			return fmt.Errorf("namespace %s: %s", name, err)
+		}
+	}
+	var override kindConfig
+	for name, override = range obj.Kinds {
+		var kind = r.Kind(name)
+		if override.Abbreviation != "" {
+			kind.Abbreviation = override.Abbreviation
+		}
+		if override.Shape != "" {
+			if !validShape(override.Shape) {
+				return fmt.Errorf("kind %s: unknown shape %q, expected one of %s", name, override.Shape, shapeNames())
+			}
+			kind.Shape = override.Shape
+		}
+		if override.Icon != "" {
+			var content []byte
+			content, err = ioutil.ReadFile(resolve(dir, override.Icon))
+			if err != nil {
+				return fmt.Errorf("kind %s: %s", name, err)
+			}
+			kind.Icon = string(content)
+		}
+		r.kinds[name] = kind
+	}
+	if obj.Icons != "" {
+		err = r.applyIcons(resolve(dir, obj.Icons), obj.Kinds)
+		if err != nil {
+			return fmt.Errorf("icons: %s", err)
+		}
+	}
+	return nil
+}
+
+// applyIcons gives the kinds the icons of dir named after their
+// abbreviations, except to those the style file gives an icon.
+func (r *Registry) applyIcons(dir string, overrides map[string]kindConfig) error {
+	var info, err = os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	var name string
+	var kind Kind
+	for name, kind = range r.kinds {
+		if kind.Placeholder || overrides[name].Icon != "" {
+			continue
+		}
+		var content []byte
+		content, err = ioutil.ReadFile(filepath.Join(dir, kind.Abbreviation+".svg"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		kind.Icon = string(content)
+		r.kinds[name] = kind
+	}
+	return nil
+}
+
+// resolve returns path, taken as relative to dir unless it is absolute.
+func resolve(dir string, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+func validShape(shape Shape) bool {
+	var s Shape
+	for _, s = range shapes {
+		if s == shape {
+			return true
+		}
+	}
+	return false
+}
+
+func shapeNames() string {
+	var result string
+	var index int
+	var s Shape
+	for index, s = range shapes {
+		if index > 0 {
+			result += ", "
+		}
+		result += string(s)
+	}
+	return result
+}
@@ -0,0 +1,64 @@
+package style
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadRegistryIcons(t *testing.T) {
+	var dir = t.TempDir()
+	var files = map[string]string{
+		"labeled/deploy.svg": "<svg>deploy</svg>",
+		"labeled/svc.svg":    "<svg>svc</svg>",
+		"rollout.svg":        "<svg>rollout</svg>",
+		"service.svg":        "<svg>service</svg>",
+		"style.yaml": `icons: labeled
+kinds:
+  Service:
+    icon: service.svg
+  Rollout:
+    abbreviation: svc
+    icon: rollout.svg
+`,
+	}
+	var name, content string
+	for name, content = range files {
+		var path = filepath.Join(dir, name)
+		var err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err == nil {
+			err = ioutil.WriteFile(path, []byte(content), 0644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var r, err = LoadRegistry(filepath.Join(dir, "style.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want = map[string]string{
+		"Deployment":     "<svg>deploy</svg>",
+		"Service":        "<svg>service</svg>",
+		"Rollout":        "<svg>rollout</svg>",
+		"Endpoints":      "",
+		"ConfigMap":      "",
+		"UnknownService": "",
+	}
+	var icon string
+	for name, icon = range want {
+		if r.Kind(name).Icon != icon {
+			t.Errorf("icon of %s is %q, want %q", name, r.Kind(name).Icon, icon)
+		}
+	}
+
+	err = ioutil.WriteFile(filepath.Join(dir, "missing.yaml"), []byte("icons: missing\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadRegistry(filepath.Join(dir, "missing.yaml"))
+	if err == nil {
+		t.Errorf("no error for a missing icon directory")
+	}
+}
@@ -0,0 +1,101 @@
+package style
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"math"
+	"strings"
+)
+
+// iconSize is the width and height of the icons, in the units of their
+// view box.
+const iconSize = 64
+
+const (
+	warningColor = "#f59f00"
+	badgeRadius  = 11
+)
+
+// Icon returns the icon of the entity as an SVG document: its kind's icon,
+// or a heptagon in Color with the abbreviation of the kind, with a badge for
+// the replicas at the bottom right and one for the warnings at the top
+// right.
+func (s Style) Icon() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Arial, sans-serif" font-weight="bold" text-anchor="middle">`, iconSize, iconSize, iconSize, iconSize)
+	switch {
+	case s.Kind.Icon != "":
+		fmt.Fprintf(&b, `<image x="4" y="4" width="56" height="56" href="data:image/svg+xml;base64,%s"/>`, base64.StdEncoding.EncodeToString([]byte(s.Kind.Icon)))
+		// The namespace is still told by a dot in its color.
+		fmt.Fprintf(&b, `<circle cx="12" cy="52" r="7" fill="%s" stroke="#ffffff" stroke-width="2"/>`, s.Color)
+	case s.Kind.Placeholder:
+		fmt.Fprintf(&b, `<polygon points="%s" fill="#ffffff" stroke="%s" stroke-width="3" stroke-dasharray="6 4"/>`, heptagon(), s.Color)
+		writeAbbreviation(&b, s.Kind.Abbreviation, s.Color)
+	default:
+		fmt.Fprintf(&b, `<polygon points="%s" fill="%s" stroke="#ffffff" stroke-width="2"/>`, heptagon(), s.Color)
+		writeAbbreviation(&b, s.Kind.Abbreviation, s.TextColor())
+	}
+	if s.HasReplicas {
+		writeBadge(&b, iconSize-badgeRadius-2, iconSize-badgeRadius-2, darkColor, count(s.Replicas))
+	}
+	if len(s.Warnings) > 0 {
+		var text = "!"
+		if len(s.Warnings) > 1 {
+			text = count(len(s.Warnings))
+		}
+		writeBadge(&b, iconSize-badgeRadius-2, badgeRadius+2, warningColor, text)
+	}
+	b.WriteString(`</svg>`)
+	return b.String()
+}
+
+// IconURI returns Icon as a data URI, which images can be drawn from.
+func (s Style) IconURI() string {
+	return "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString([]byte(s.Icon()))
+}
+
+// heptagon returns the points of the outline of the generated icons, a
+// heptagon standing on one side like those of the Kubernetes icon set.
+func heptagon() string {
+	var points = []string{}
+	var index int
+	for index = 0; index < 7; index++ {
+		var angle = (-90 + 360*float64(index)/7) * math.Pi / 180
+		points = append(points, fmt.Sprintf("%.1f,%.1f", 32+28*math.Cos(angle), 34+28*math.Sin(angle)))
+	}
+	return strings.Join(points, " ")
+}
+
+// writeAbbreviation writes text in the middle of the heptagon, smaller the
+// longer it is.
+func writeAbbreviation(b *strings.Builder, text string, color string) {
+	var size = 18
+	var length = len([]rune(text))
+	switch {
+	case length > 6:
+		size = 9
+	case length > 4:
+		size = 11
+	case length > 3:
+		size = 15
+	}
+	fmt.Fprintf(b, `<text x="32" y="%.1f" font-size="%d" fill="%s">%s</text>`, 35+float64(size)*0.35, size, color, html.EscapeString(text))
+}
+
+func writeBadge(b *strings.Builder, x, y int, color string, text string) {
+	fmt.Fprintf(b, `<circle cx="%d" cy="%d" r="%d" fill="%s" stroke="#ffffff" stroke-width="2"/>`, x, y, badgeRadius, color)
+	var size = 12
+	if len(text) > 2 {
+		size = 9
+	}
+	fmt.Fprintf(b, `<text x="%d" y="%.1f" font-size="%d" fill="#ffffff">%s</text>`, x, float64(y)+float64(size)*0.35, size, html.EscapeString(text))
+}
+
+// count returns n as written in a badge, which has room for two digits.
+func count(n int) string {
+	if n > 99 {
+		return "99+"
+	}
+	return fmt.Sprint(n)
+}
@@ -0,0 +1,345 @@
+// Package style decides how entities are drawn: an icon per kind, a color
+// per namespace, and badges for the replicas of workloads and the warnings
+// lint found on them. The window and every export draw entities from the
+// same Registry, so that they look alike everywhere.
+//
+// Icons are generated in the style of the Kubernetes community icon set, a
+// heptagon with the abbreviation of the kind, rather than copied from it:
+// the official icons are not bundled. A style file can point at a copy of
+// them, or give other artwork per kind.
+//
+// Objects can override their style with annotations:
+//
+//	metadata:
+//	  annotations:
+//	    k8s-visualizer/color: "#e8590c"
+//	    k8s-visualizer/abbreviation: cart
+package style
+
+import (
+	"fmt"
+	"hash/fnv"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/gkawamoto/k8s-visualizer/dependency"
+)
+
+const (
+	// AnnotationColor overrides the color of an object, which is the color
+	// of its namespace otherwise.
+	AnnotationColor = "k8s-visualizer/color"
+	// AnnotationAbbreviation overrides the abbreviation drawn in the icon
+	// of an object.
+	AnnotationAbbreviation = "k8s-visualizer/abbreviation"
+)
+
+// Shape is the outline drawn for a kind where icons cannot be used. Shapes
+// are named after vis.js shapes.
+type Shape string
+
+// The shapes a kind can be configured with.
+const (
+	ShapeDot          Shape = "dot"
+	ShapeDiamond      Shape = "diamond"
+	ShapeSquare       Shape = "square"
+	ShapeTriangle     Shape = "triangle"
+	ShapeTriangleDown Shape = "triangleDown"
+	ShapeHexagon      Shape = "hexagon"
+	ShapeStar         Shape = "star"
+)
+
+var shapes = []Shape{ShapeDot, ShapeDiamond, ShapeSquare, ShapeTriangle, ShapeTriangleDown, ShapeHexagon, ShapeStar}
+
+// Kind is how the entities of one kind are drawn.
+type Kind struct {
+	// Abbreviation is written in the generated icon.
+	Abbreviation string
+	// Shape is the outline used by the formats that cannot show icons.
+	Shape Shape
+	// Icon is an SVG document drawn instead of the generated icon.
+	Icon string
+	// Placeholder kinds stand for objects no manifest defines. Their
+	// icon is only outlined.
+	Placeholder bool
+}
+
+// Style is how one entity is drawn.
+type Style struct {
+	Kind Kind
+	// Color is the fill color, "#rrggbb".
+	Color string
+	// Replicas is the replica count shown in a badge when HasReplicas.
+	Replicas    int
+	HasReplicas bool
+	// Warnings are shown as a badge with their count, and listed in the
+	// tooltip.
+	Warnings []string
+}
+
+// TextColor returns the color of text written over Color, dark or white
+// whichever reads better.
+func (s Style) TextColor() string {
+	var r, g, b = rgb(s.Color)
+	if 0.299*r+0.587*g+0.114*b > 160 {
+		return darkColor
+	}
+	return "#ffffff"
+}
+
+// Tooltip returns the HTML shown when the pointer rests on the entity, or
+// "" when there is nothing to tell.
+func (s Style) Tooltip() string {
+	if len(s.Warnings) == 0 {
+		return ""
+	}
+	var lines = []string{}
+	var warning string
+	for _, warning = range s.Warnings {
+		lines = append(lines, "&#9888; "+html.EscapeString(warning))
+	}
+	return strings.Join(lines, "<br>")
+}
+
+const (
+	darkColor = "#343434"
+	// legendColor is the color of the kind icons in legends, where they
+	// stand for every namespace.
+	legendColor = "#326ce5"
+)
+
+// defaultKinds are the kinds the registry knows without configuration,
+// abbreviated as in the Kubernetes icon set. The first four keep the shapes
+// they always had.
+var defaultKinds = map[string]Kind{
+	"Ingress":                     {Abbreviation: "ing", Shape: ShapeDot},
+	"Service":                     {Abbreviation: "svc", Shape: ShapeDiamond},
+	"Deployment":                  {Abbreviation: "deploy", Shape: ShapeSquare},
+	"DaemonSet":                   {Abbreviation: "ds", Shape: ShapeTriangle},
+	"StatefulSet":                 {Abbreviation: "sts", Shape: ShapeSquare},
+	"ReplicaSet":                  {Abbreviation: "rs", Shape: ShapeSquare},
+	"Pod":                         {Abbreviation: "pod", Shape: ShapeSquare},
+	"Job":                         {Abbreviation: "job", Shape: ShapeSquare},
+	"CronJob":                     {Abbreviation: "cronjob", Shape: ShapeSquare},
+	"VirtualService":              {Abbreviation: "vs", Shape: ShapeDot},
+	"ConfigMap":                   {Abbreviation: "cm", Shape: ShapeHexagon},
+	"Secret":                      {Abbreviation: "secret", Shape: ShapeHexagon},
+	"PersistentVolumeClaim":       {Abbreviation: "pvc", Shape: ShapeHexagon},
+	"PersistentVolume":            {Abbreviation: "pv", Shape: ShapeHexagon},
+	"StorageClass":                {Abbreviation: "sc", Shape: ShapeHexagon},
+	"HorizontalPodAutoscaler":     {Abbreviation: "hpa", Shape: ShapeStar},
+	"NetworkPolicy":               {Abbreviation: "netpol", Shape: ShapeStar},
+	"ServiceAccount":              {Abbreviation: "sa", Shape: ShapeStar},
+	"Role":                        {Abbreviation: "role", Shape: ShapeStar},
+	"ClusterRole":                 {Abbreviation: "c-role", Shape: ShapeStar},
+	"RoleBinding":                 {Abbreviation: "rb", Shape: ShapeStar},
+	"ClusterRoleBinding":          {Abbreviation: "crb", Shape: ShapeStar},
+	"Namespace":                   {Abbreviation: "ns", Shape: ShapeStar},
+	"Endpoints":                   {Abbreviation: "ep", Shape: ShapeDiamond},
+	dependency.KindUnknownService: {Abbreviation: "svc", Shape: ShapeTriangleDown, Placeholder: true},
+}
+
+// defaultPalette holds colors that white text reads well on.
+var defaultPalette = []string{
+	"#326ce5", "#d9480f", "#2b8a3e", "#862e9c", "#c92a2a", "#1098ad",
+	"#5f3dc4", "#e67700", "#087f5b", "#a61e4d", "#364fc7", "#5c940d",
+}
+
+// Registry holds how every kind and namespace is drawn. A nil *Registry
+// draws with the defaults.
+type Registry struct {
+	kinds      map[string]Kind
+	namespaces map[string]string
+	palette    []string
+}
+
+// NewRegistry returns a registry with the default kinds and palette.
+func NewRegistry() *Registry {
+	var result = &Registry{
+		kinds:      map[string]Kind{},
+		namespaces: map[string]string{},
+		palette:    defaultPalette,
+	}
+	var name string
+	var kind Kind
+	for name, kind = range defaultKinds {
+		result.kinds[name] = kind
+	}
+	return result
+}
+
+var defaultRegistry = NewRegistry()
+
+func (r *Registry) registry() *Registry {
+	if r == nil {
+		return defaultRegistry
+	}
+	return r
+}
+
+// Kind returns how the entities of kind are drawn. Kinds the registry does
+// not know are abbreviated from their capitals, Rollout as "r" and
+// ServiceMonitor as "sm", with the shape of unknown kinds.
+func (r *Registry) Kind(kind string) Kind {
+	var result, ok = r.registry().kinds[kind]
+	if ok {
+		return result
+	}
+	var abbreviation string
+	var c rune
+	for _, c = range kind {
+		if c >= 'A' && c <= 'Z' {
+			abbreviation += string(c - 'A' + 'a')
+		}
+	}
+	if abbreviation == "" {
+		abbreviation = "?"
+	}
+	return Kind{Abbreviation: abbreviation, Shape: ShapeTriangleDown}
+}
+
+// NamespaceColor returns the color of namespace: the one configured for it,
+// the first of the palette for "default", or one of the palette picked from
+// its name, so that a namespace keeps its color across runs and whatever
+// other namespaces are shown.
+func (r *Registry) NamespaceColor(namespace string) string {
+	r = r.registry()
+	if namespace == "" {
+		namespace = "default"
+	}
+	var result, ok = r.namespaces[namespace]
+	if ok {
+		return result
+	}
+	if namespace == "default" {
+		return r.palette[0]
+	}
+	var h = fnv.New32a()
+	h.Write([]byte(namespace))
+	return r.palette[int(h.Sum32()%uint32(len(r.palette)))]
+}
+
+// Styles returns the style of every entity of graph, keyed by entity ID.
+// Warnings are the lint warnings of the manifest defining the entity, and
+// annotations that could not be used.
+func (r *Registry) Styles(graph *dependency.Graph) map[string]Style {
+	var warnings = map[dependency.Source][]string{}
+	var d dependency.Diagnostic
+	for _, d = range graph.Lint() {
+		warnings[d.Source] = append(warnings[d.Source], d.Message)
+	}
+	var result = map[string]Style{}
+	var e *dependency.Entity
+	for _, e = range graph.Nodes() {
+		var s = r.Style(e)
+		if e.Source.File != "" {
+			s.Warnings = append(s.Warnings, warnings[e.Source]...)
+		}
+		result[e.ID] = s
+	}
+	return result
+}
+
+// Style returns the style of e, without lint warnings.
+func (r *Registry) Style(e *dependency.Entity) Style {
+	var result = Style{
+		Kind:  r.Kind(e.Kind),
+		Color: r.NamespaceColor(e.Metadata.Namespace),
+	}
+	result.Replicas, result.HasReplicas = e.Replicas()
+	var value, ok = e.Metadata.Annotations[AnnotationColor]
+	if ok {
+		var color, err = parseColor(value)
+		if err == nil {
+			result.Color = color
+		} else {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("annotation %s: %s", AnnotationColor, err))
+		}
+	}
+	value, ok = e.Metadata.Annotations[AnnotationAbbreviation]
+	if ok && strings.TrimSpace(value) != "" {
+		result.Kind.Abbreviation = strings.TrimSpace(value)
+	}
+	return result
+}
+
+// Legend tells what the icons and colors of a graph stand for.
+type Legend struct {
+	Kinds      []LegendKind      `json:"kinds"`
+	Namespaces []LegendNamespace `json:"namespaces"`
+}
+
+// LegendKind is a kind of the graph and its icon.
+type LegendKind struct {
+	Kind string `json:"kind"`
+	Icon string `json:"icon"`
+}
+
+// LegendNamespace is a namespace of the graph and its color.
+type LegendNamespace struct {
+	Namespace string `json:"namespace"`
+	Color     string `json:"color"`
+}
+
+// Legend returns the legend of the kinds and namespaces of graph, sorted by
+// name. Kind icons are drawn in a single color, as they stand for every
+// namespace.
+func (r *Registry) Legend(graph *dependency.Graph) Legend {
+	var kinds = map[string]bool{}
+	var namespaces = map[string]bool{}
+	var e *dependency.Entity
+	for _, e = range graph.Nodes() {
+		kinds[e.Kind] = true
+		if e.Metadata.Namespace == "" {
+			namespaces["default"] = true
+		} else {
+			namespaces[e.Metadata.Namespace] = true
+		}
+	}
+	var result = Legend{Kinds: []LegendKind{}, Namespaces: []LegendNamespace{}}
+	var name string
+	for _, name = range sortedNames(kinds) {
+		var s = Style{Kind: r.Kind(name), Color: legendColor}
+		result.Kinds = append(result.Kinds, LegendKind{name, s.IconURI()})
+	}
+	for _, name = range sortedNames(namespaces) {
+		result.Namespaces = append(result.Namespaces, LegendNamespace{name, r.NamespaceColor(name)})
+	}
+	return result
+}
+
+func sortedNames(set map[string]bool) []string {
+	var result = []string{}
+	var name string
+	for name = range set {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// parseColor accepts "#rgb" and "#rrggbb" colors and returns them as
+// "#rrggbb", in lower case.
+func parseColor(value string) (string, error) {
+	var s = strings.ToLower(strings.TrimSpace(value))
+	var valid = strings.HasPrefix(s, "#") && (len(s) == 4 || len(s) == 7)
+	var c rune
+	for _, c = range strings.TrimPrefix(s, "#") {
+		valid = valid && (c >= '0' && c <= '9' || c >= 'a' && c <= 'f')
+	}
+	if !valid {
+		return "", fmt.Errorf("%q is not a #rrggbb color", value)
+	}
+	if len(s) == 4 {
+		s = "#" + strings.Repeat(s[1:2], 2) + strings.Repeat(s[2:3], 2) + strings.Repeat(s[3:4], 2)
+	}
+	return s, nil
+}
+
+// rgb returns the components of a color returned by parseColor.
+func rgb(color string) (float64, float64, float64) {
+	var r, g, b int
+	fmt.Sscanf(color, "#%02x%02x%02x", &r, &g, &b)
+	return float64(r), float64(g), float64(b)
+}
@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// changeSet collects the node and edge changes waiting to be sent to the
+// page. Only the last change of each item is kept. Icons are the images the
+// page has not received yet, keyed by the Icon of the nodes using them.
+type changeSet struct {
+	Nodes datasetChanges    `json:"nodes"`
+	Edges datasetChanges    `json:"edges"`
+	Icons map[string]string `json:"icons"`
+}
+
+// datasetChanges are the changes of one DataSet of the page, keyed by the
+// IDs of its items.
+type datasetChanges struct {
+	updates map[string]interface{}
+	removes map[string]bool
+}
+
+func newChangeSet() changeSet {
+	return changeSet{
+		Nodes: newDatasetChanges(),
+		Edges: newDatasetChanges(),
+		Icons: map[string]string{},
+	}
+}
+
+func newDatasetChanges() datasetChanges {
+	return datasetChanges{
+		updates: map[string]interface{}{},
+		removes: map[string]bool{},
+	}
+}
+
+func (c *changeSet) empty() bool {
+	return len(c.Nodes.updates)+len(c.Nodes.removes)+len(c.Edges.updates)+len(c.Edges.removes) == 0
+}
+
+func (c *changeSet) updateNode(obj node) {
+	c.Nodes.update(obj.ID, obj)
+}
+
+func (c *changeSet) removeNode(id string) {
+	c.Nodes.remove(id)
+}
+
+func (c *changeSet) updateEdge(obj edge) {
+	c.Edges.update(obj.ID, obj)
+}
+
+func (c *changeSet) removeEdge(id string) {
+	c.Edges.remove(id)
+}
+
+func (d *datasetChanges) update(id string, obj interface{}) {
+	delete(d.removes, id)
+	d.updates[id] = obj
+}
+
+func (d *datasetChanges) remove(id string) {
+	delete(d.updates, id)
+	d.removes[id] = true
+}
+
+// MarshalJSON encodes the changes as the update and remove lists expected by
+// applyChanges in the page, sorted by ID so that the same changes always
+// encode the same way.
+func (d datasetChanges) MarshalJSON() ([]byte, error) {
+	var result = struct {
+		Update []interface{} `json:"update"`
+		Remove []string      `json:"remove"`
+	}{[]interface{}{}, []string{}}
+	var ids = []string{}
+	var id string
+	for id = range d.updates {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id = range ids {
+		result.Update = append(result.Update, d.updates[id])
+	}
+	for id = range d.removes {
+		result.Remove = append(result.Remove, id)
+	}
+	sort.Strings(result.Remove)
+	return json.Marshal(&result)
+}
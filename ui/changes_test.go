@@ -0,0 +1,29 @@
+package ui
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestChangeSetEncodesSortedByID(t *testing.T) {
+	var want = `{"nodes":{"update":[{"label":"a","id":"a","shape":""},{"label":"b","id":"b","shape":""},{"label":"c","id":"c","shape":""}],"remove":["d","e","f"]},` +
+		`"edges":{"update":[],"remove":[]},"icons":{}}`
+	var attempt int
+	for attempt = 0; attempt < 10; attempt++ {
+		var c = newChangeSet()
+		var id string
+		for _, id = range []string{"c", "a", "b"} {
+			c.updateNode(node{Label: id, ID: id})
+		}
+		for _, id = range []string{"f", "d", "e"} {
+			c.removeNode(id)
+		}
+		var data, err = json.Marshal(&c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Fatalf("got %s, want %s", data, want)
+		}
+	}
+}
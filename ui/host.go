@@ -0,0 +1,159 @@
+package ui
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/zserge/webview"
+)
+
+// host shows the page of a Window: a webview window, or a browser connected
+// to a Server.
+type host interface {
+	// eval evaluates content in the page without waiting for the result.
+	// Errors are reported to onError.
+	eval(content string, onError func(error))
+	setTitle(title string)
+	terminate()
+}
+
+// webviewHost is a webview window. Calls into the webview are dispatched onto
+// the UI thread.
+type webviewHost struct {
+	view webview.WebView
+}
+
+func (h webviewHost) eval(content string, onError func(error)) {
+	h.view.Dispatch(func() {
+		var err = h.view.Eval(content)
+		if err != nil {
+			onError(err)
+		}
+	})
+}
+
+func (h webviewHost) setTitle(title string) {
+	h.view.Dispatch(func() {
+		h.view.SetTitle(title)
+	})
+}
+
+func (h webviewHost) terminate() {
+	h.view.Terminate()
+}
+
+// socketWriteTimeout is how long a browser has to accept a message before its
+// connection is considered lost.
+const socketWriteTimeout = 10 * time.Second
+
+// socketQueueSize is how many messages can wait to be written to a browser.
+// A browser falling further behind is disconnected.
+const socketQueueSize = 1024
+
+// socketHost is a browser connected over a WebSocket. The page evaluates the
+// content of the messages it receives, which makes the socket a drop-in
+// replacement for the webview's Eval.
+//
+// Messages are queued and written by a goroutine of their own, so that
+// sending never waits for the browser, even when the caller holds a lock.
+type socketHost struct {
+	conn   *websocket.Conn
+	queue  chan socketMessage
+	closed chan struct{}
+	once   sync.Once
+}
+
+type socketMessage struct {
+	Eval    string `json:"eval,omitempty"`
+	Title   string `json:"title,omitempty"`
+	onError func(error)
+}
+
+func newSocketHost(conn *websocket.Conn) *socketHost {
+	var result = &socketHost{
+		conn:   conn,
+		queue:  make(chan socketMessage, socketQueueSize),
+		closed: make(chan struct{}),
+	}
+	go result.write()
+	return result
+}
+
+// write writes the queued messages until the connection is closed. The first
+// failure closes it.
+func (h *socketHost) write() {
+	for {
+		var message socketMessage
+		select {
+		case message = <-h.queue:
+		case <-h.closed:
+			return
+		}
+		var err = h.conn.SetWriteDeadline(time.Now().Add(socketWriteTimeout))
+		if err == nil {
+			err = h.conn.WriteJSON(&message)
+		}
+		if err != nil {
+			h.fail(message, err)
+			return
+		}
+	}
+}
+
+// send queues message. Messages sent once the connection is closed are
+// dropped.
+func (h *socketHost) send(message socketMessage) {
+	select {
+	case <-h.closed:
+	case h.queue <- message:
+	default:
+		h.fail(message, fmt.Errorf("%d messages waiting, the browser does not keep up", socketQueueSize))
+	}
+}
+
+func (h *socketHost) fail(message socketMessage, err error) {
+	if message.onError != nil {
+		message.onError(fmt.Errorf("socket: %s", err))
+	}
+	h.terminate()
+}
+
+func (h *socketHost) eval(content string, onError func(error)) {
+	h.send(socketMessage{Eval: content, onError: onError})
+}
+
+func (h *socketHost) setTitle(title string) {
+	h.send(socketMessage{Title: title})
+}
+
+func (h *socketHost) terminate() {
+	h.once.Do(func() {
+		close(h.closed)
+		h.conn.Close()
+	})
+}
+
+// recordingHost keeps what would be evaluated in a page instead of showing
+// one, so that the page can replay it later.
+type recordingHost struct {
+	lock    sync.Mutex
+	title   string
+	scripts []string
+}
+
+func (h *recordingHost) eval(content string, onError func(error)) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.scripts = append(h.scripts, content)
+}
+
+func (h *recordingHost) setTitle(title string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.title = title
+}
+
+func (h *recordingHost) terminate() {
+}
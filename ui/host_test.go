@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestSocketSendDoesNotWaitForTheBrowser sends to a browser that never reads:
+// sending must return at once, and the connection must be dropped once the
+// queue is full.
+func TestSocketSendDoesNotWaitForTheBrowser(t *testing.T) {
+	var hosts = make(chan *socketHost, 1)
+	var upgrader websocket.Upgrader
+	var server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var conn, err = upgrader.Upgrade(rw, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		hosts <- newSocketHost(conn)
+	}))
+	defer server.Close()
+	var client, _, err = websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	var h = <-hosts
+
+	var lock sync.Mutex
+	var failures []error
+	var content = strings.Repeat("x", 64*1024)
+	var start = time.Now()
+	var index int
+	for index = 0; index < 2*socketQueueSize; index++ {
+		h.eval(content, func(err error) {
+			lock.Lock()
+			defer lock.Unlock()
+			failures = append(failures, err)
+		})
+	}
+	if time.Since(start) > socketWriteTimeout/2 {
+		t.Fatalf("sending took %s", time.Since(start))
+	}
+	select {
+	case <-h.closed:
+	case <-time.After(time.Second):
+		t.Fatalf("connection still open with a full queue")
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if len(failures) == 0 || !strings.Contains(failures[0].Error(), "does not keep up") {
+		t.Fatalf("got failures %v, want the queue to overflow", failures)
+	}
+}
@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// NewRecorder returns a Window without a page. What would be evaluated in
+// the page is recorded instead, to be replayed by a page that has no
+// connection to Go, such as a standalone HTML export. Messages of the page
+// are simulated with Send and Call.
+func NewRecorder(errorHandler func(error)) *Window {
+	var result = newWindow(errorHandler)
+	result.host = &recordingHost{}
+	return result
+}
+
+// Recording returns the title set and the scripts evaluated so far in a
+// window returned by NewRecorder.
+func (w *Window) Recording() (string, []string, error) {
+	var h, ok = w.currentHost().(*recordingHost)
+	if !ok {
+		return "", nil, fmt.Errorf("window: recording: not a recorder")
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.title, append([]string{}, h.scripts...), nil
+}
+
+// Send delivers a message to the listener registered for event, as if the
+// page had sent it with communicate(event, data).
+func (w *Window) Send(event string, data interface{}) error {
+	var payload []byte
+	var err error
+	payload, err = json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("window: send: json: marshal: %s", err)
+	}
+	w.invoke(event, string(payload))
+	return nil
+}
+
+// Call calls the handler registered for method, as if the page had called
+// call(method, args...), and returns its result.
+func (w *Window) Call(method string, args ...interface{}) (interface{}, error) {
+	var raw = []json.RawMessage{}
+	var arg interface{}
+	for _, arg = range args {
+		var content, err = json.Marshal(arg)
+		if err != nil {
+			return nil, fmt.Errorf("window: call: %s: json: marshal: %s", method, err)
+		}
+		raw = append(raw, content)
+	}
+	return w.dispatchCall(method, raw)
+}
@@ -0,0 +1,153 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"runtime/debug"
+)
+
+// handler is a Go function registered with Handle, called with arguments
+// decoded from the JSON sent by the page.
+type handler struct {
+	fn     reflect.Value
+	args   []reflect.Type
+	result bool
+}
+
+type response struct {
+	ID     int         `json:"id"`
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Handle registers fn as the handler for calls to method made from the page
+// with call(method, args...). fn can take any number of JSON-decodable
+// arguments and return either nothing, an error, a result, or a result and an
+// error. The page receives the result, or the error, through the promise
+// returned by call.
+func (w *Window) Handle(method string, fn interface{}) error {
+	var value = reflect.ValueOf(fn)
+	var t = value.Type()
+	if t.Kind() != reflect.Func {
+		return fmt.Errorf("window: handle: %s: handler is not a function", method)
+	}
+	var h = handler{fn: value}
+	var index int
+	for index = 0; index < t.NumIn(); index++ {
+		h.args = append(h.args, t.In(index))
+	}
+	switch t.NumOut() {
+	case 0:
+	case 1:
+		h.result = t.Out(0) != errorType
+	case 2:
+		if t.Out(1) != errorType {
+			return fmt.Errorf("window: handle: %s: second result must be an error", method)
+		}
+		h.result = true
+	default:
+		return fmt.Errorf("window: handle: %s: too many results", method)
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.handlers[method] = h
+	return nil
+}
+
+func (w *Window) call(id int, method string, args []json.RawMessage) {
+	var result interface{}
+	var err error
+	result, err = w.dispatchCall(method, args)
+	var obj = response{ID: id, Result: result}
+	if err != nil {
+		obj.Error = err.Error()
+	}
+	var content []byte
+	content, err = json.Marshal(&obj)
+	if err != nil {
+		content, _ = json.Marshal(&response{ID: id, Error: fmt.Sprintf("json: marshal: %s", err)})
+	}
+	err = w.Eval(fmt.Sprintf("rpc.settle(%s)", string(content)))
+	if err != nil {
+		w.invokeError(fmt.Errorf("window: call: %s: eval: %s", method, err))
+	}
+}
+
+func (w *Window) dispatchCall(method string, args []json.RawMessage) (interface{}, error) {
+	var h handler
+	var ok bool
+	w.lock.Lock()
+	h, ok = w.handlers[method]
+	w.lock.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown method %q", method)
+	}
+	if len(args) != len(h.args) {
+		return nil, fmt.Errorf("%s: expected %d arguments, got %d", method, len(h.args), len(args))
+	}
+	var in = make([]reflect.Value, len(args))
+	var index int
+	var arg json.RawMessage
+	for index, arg = range args {
+		var value = reflect.New(h.args[index])
+		var err = json.Unmarshal(arg, value.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("%s: argument %d: %s", method, index, err)
+		}
+		in[index] = value.Elem()
+	}
+	var out, err = invoke(method, h, in)
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	var last reflect.Value
+	if h.result {
+		result = out[0].Interface()
+	}
+	if len(out) > 0 {
+		last = out[len(out)-1]
+	}
+	if last.IsValid() && last.Type() == errorType && !last.IsNil() {
+		return nil, last.Interface().(error)
+	}
+	return result, nil
+}
+
+// invoke calls the handler, turning a panic into an error so that a bad
+// handler fails the call rather than the whole process, which serves every
+// other window too.
+func invoke(method string, h handler, in []reflect.Value) (out []reflect.Value, err error) {
+	defer func() {
+		var value = recover()
+		if value != nil {
+			log.Printf("window: call: %s: panic: %v\n%s", method, value, debug.Stack())
+			err = fmt.Errorf("%s: panic: %v", method, value)
+		}
+	}()
+	return h.fn.Call(in), nil
+}
+
+// Emit sends an event to the page, where it is delivered to the listeners
+// added with on(event, listener).
+func (w *Window) Emit(event string, data interface{}) error {
+	var name, content []byte
+	var err error
+	name, err = json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("window: emit: json: marshal: %s", err)
+	}
+	content, err = json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("window: emit: json: marshal: %s", err)
+	}
+	err = w.Eval(fmt.Sprintf("emit(%s, %s)", string(name), string(content)))
+	if err != nil {
+		return fmt.Errorf("window: emit: eval: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package ui
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCallRecoversFromPanic(t *testing.T) {
+	var w = &Window{handlers: map[string]handler{}}
+	var err = w.Handle("explode", func(value map[string]interface{}) string {
+		return value["name"].(string)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = w.dispatchCall("explode", []json.RawMessage{json.RawMessage(`{"name": 1}`)})
+	if err == nil || !strings.Contains(err.Error(), "explode: panic:") {
+		t.Fatalf("got error %v, want a panic error", err)
+	}
+	var result interface{}
+	result, err = w.dispatchCall("explode", []json.RawMessage{json.RawMessage(`{"name": "still serving"}`)})
+	if err != nil || result != "still serving" {
+		t.Fatalf("got %v, %v after a panic, want the result", result, err)
+	}
+}
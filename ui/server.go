@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+// Server serves the page to browsers instead of a webview window. Every
+// browser connecting to it gets a Window of its own, passed to OnWindow,
+// talking to the page over a WebSocket.
+type Server struct {
+	listener     net.Listener
+	http         *http.Server
+	mux          *http.ServeMux
+	upgrader     websocket.Upgrader
+	errorHandler func(error)
+	// OnWindow is called with the Window of every new connection, before
+	// any message of the page is read, so that it can register listeners
+	// and handlers. It must be set before Run.
+	OnWindow func(w *Window)
+}
+
+// NewServer listens on address, such as "127.0.0.1:8080" or ":8080". Errors
+// of the windows go to errorHandler, or to the log if it is nil.
+func NewServer(address string, errorHandler func(error)) (*Server, error) {
+	var result = Server{errorHandler: errorHandler}
+	var listener net.Listener
+	var mux *http.ServeMux
+	var err error
+	listener, mux, err = newHTTPServer(address)
+	if err != nil {
+		return nil, err
+	}
+	mux.HandleFunc("/socket", result.handleSocket)
+	mux.Handle("/", http.RedirectHandler("/public/", http.StatusFound))
+	result.listener = listener
+	result.mux = mux
+	result.http = &http.Server{Handler: mux}
+	return &result, nil
+}
+
+// Handle serves handler under pattern next to the page, as
+// http.ServeMux.Handle does. It must be called before Run.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// URL returns the address of the page.
+func (s *Server) URL() string {
+	return fmt.Sprintf("http://%s/public/", s.listener.Addr().String())
+}
+
+// Run serves browsers until Close is called.
+func (s *Server) Run() error {
+	var err = s.http.Serve(s.listener)
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// Close stops the server and closes every connection.
+func (s *Server) Close() error {
+	return s.http.Close()
+}
+
+func (s *Server) handleSocket(rw http.ResponseWriter, r *http.Request) {
+	var conn *websocket.Conn
+	var err error
+	conn, err = s.upgrader.Upgrade(rw, r, nil)
+	if err != nil {
+		// The upgrader already answered with an error status.
+		return
+	}
+	var w = newWindow(s.errorHandler)
+	w.host = newSocketHost(conn)
+	if s.OnWindow != nil {
+		s.OnWindow(w)
+	}
+	defer w.Terminate()
+	for {
+		var data []byte
+		_, data, err = conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		w.receive(string(data))
+	}
+}
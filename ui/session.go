@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Session shares the view of one window, the presenter, with the other
+// windows that joined it, so that everyone sees the same selection, viewport
+// and filters during a walkthrough. Any window can start presenting, which
+// takes over from the current presenter. The view state is opaque to Go: it
+// is whatever the presenter's page sends, and is handed as is to the other
+// pages, which decide whether to follow it.
+type Session struct {
+	lock      sync.Mutex
+	members   map[*Window]bool
+	presenter *Window
+	state     json.RawMessage
+}
+
+type sessionRole struct {
+	// Presenter tells whether the window receiving the role presents.
+	Presenter bool `json:"presenter"`
+	// Active tells whether anyone presents.
+	Active bool `json:"active"`
+}
+
+// NewSession returns a session without members.
+func NewSession() *Session {
+	return &Session{members: map[*Window]bool{}}
+}
+
+// Join adds w to the session until it is closed. The page of w sends
+// session.join once loaded, then session.present, session.stop and
+// session.state, and receives the session.role and session.state events.
+func (s *Session) Join(w *Window) {
+	w.Register("session.present", func(data []byte) {
+		s.present(w)
+	})
+	w.Register("session.stop", func(data []byte) {
+		s.stop(w)
+	})
+	w.Register("session.state", func(data []byte) {
+		s.update(w, data)
+	})
+	w.Register("session.join", func(data []byte) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		s.sendRole(w)
+		if s.presenter != nil && s.state != nil {
+			s.emit(w, "session.state", s.state)
+		}
+	})
+	w.OnClose(func() {
+		s.stop(w)
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		delete(s.members, w)
+	})
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.members[w] = true
+}
+
+func (s *Session) present(w *Window) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.presenter = w
+	s.state = nil
+	s.sendRoles()
+}
+
+func (s *Session) stop(w *Window) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.presenter != w {
+		return
+	}
+	s.presenter = nil
+	s.state = nil
+	s.sendRoles()
+}
+
+// update stores the state sent by the presenter and forwards it to the other
+// members. States sent by other windows are ignored.
+func (s *Session) update(w *Window, data []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.presenter != w || !json.Valid(data) {
+		return
+	}
+	s.state = json.RawMessage(data)
+	var member *Window
+	for member = range s.members {
+		if member != w {
+			s.emit(member, "session.state", s.state)
+		}
+	}
+}
+
+func (s *Session) sendRoles() {
+	var member *Window
+	for member = range s.members {
+		s.sendRole(member)
+	}
+}
+
+func (s *Session) sendRole(w *Window) {
+	s.emit(w, "session.role", sessionRole{s.presenter == w, s.presenter != nil})
+}
+
+func (s *Session) emit(w *Window, event string, data interface{}) {
+	var err = w.Emit(event, data)
+	if err != nil {
+		w.invokeError(err)
+	}
+}
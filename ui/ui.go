@@ -6,89 +6,93 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"sync"
 
 	"github.com/rakyll/statik/fs"
 	"github.com/zserge/webview"
 )
 
-// Window ?
+// Window is a page showing a vis.js network, in a webview or in the
+// browsers connected to a Server, and the Go side of its events and calls.
+//
+// A Window is safe for concurrent use. Its state is guarded by a mutex and
+// every call into the webview is dispatched onto the UI thread.
 type Window struct {
-	view   webview.WebView
+	lock   sync.Mutex
+	host   host
 	server struct {
 		listener net.Listener
 		http     *http.Server
 	}
-	nodes        []node
-	edges        []edge
-	OnReady      func()
-	errorHandler func(error)
-	listeners    listenerMap
+	nodes         map[string]node
+	edges         map[string]edge
+	changes       changeSet
+	OnReady       func()
+	closeHandlers []func()
+	errorHandler  func(error)
+	listeners     listenerMap
+	handlers      map[string]handler
+	// icons maps the images sent to the page to their keys.
+	icons map[string]string
 }
 
 type listenerMap map[string]func([]byte)
 
 type node struct {
-	Label string   `json:"label"`
-	ID    int      `json:"id"`
-	Shape NodeKind `json:"shape"`
+	Label string `json:"label"`
+	ID    string `json:"id"`
+	Shape string `json:"shape"`
+	// Icon is the key of the image in the icons sent to the page.
+	Icon  string `json:"icon,omitempty"`
+	Title string `json:"title,omitempty"`
 }
 
 type edge struct {
-	From int `json:"from"`
-	To   int `json:"to"`
+	ID   string `json:"id"`
+	From string `json:"from"`
+	To   string `json:"to"`
 }
 type payload struct {
-	Method  string `json:"method"`
-	Payload string `json:"payload"`
+	ID      int               `json:"id"`
+	Method  string            `json:"method"`
+	Payload string            `json:"payload"`
+	Args    []json.RawMessage `json:"args"`
 }
 
-// NodeKind ?
-type NodeKind string
-
-const (
-	// NodeKindIngress ?
-	NodeKindIngress NodeKind = "dot"
-	// NodeKindService ?
-	NodeKindService NodeKind = "diamond"
-	// NodeKindDeployment ?
-	NodeKindDeployment NodeKind = "square"
-	// NodeKindDaemonSet ?
-	NodeKindDaemonSet NodeKind = "triangle"
-
-	// NodeKindUnknown ?
-	NodeKindUnknown NodeKind = "triangleDown"
-)
-
-// KubernetesKindToNodeKind ?
-func KubernetesKindToNodeKind(kind string) NodeKind {
-	switch kind {
-	case "Ingress":
-		return NodeKindIngress
-	case "Service":
-		return NodeKindService
-	case "Deployment":
-		return NodeKindDeployment
-	case "DaemonSet":
-		return NodeKindDaemonSet
-	}
-	return NodeKindUnknown
+// NodeStyle is how a node is drawn: as Image when it is set, as the vis.js
+// Shape otherwise.
+type NodeStyle struct {
+	Shape string
+	// Image is the URL of the image, usually a data URI. Each image is
+	// sent to the page once, however many nodes use it.
+	Image string
+	// Title is HTML shown when the pointer rests on the node.
+	Title string
 }
 
-// New ?
+// New opens a webview window serving the page on a local port.
+// errorHandler receives the errors of the page and of its handlers; when it
+// is nil they are logged.
 func New(errorHandler func(error)) (*Window, error) {
-	var result = Window{
-		nodes:        []node{},
-		edges:        []edge{},
-		errorHandler: errorHandler,
-		listeners:    listenerMap{},
-	}
-	result.nodes = []node{}
+	var result = newWindow(errorHandler)
 	var err = result.createHTTPServer()
 	if err != nil {
 		return nil, err
 	}
 	result.createWebView()
-	return &result, nil
+	return result, nil
+}
+
+func newWindow(errorHandler func(error)) *Window {
+	return &Window{
+		nodes:        map[string]node{},
+		edges:        map[string]edge{},
+		changes:      newChangeSet(),
+		icons:        map[string]string{},
+		errorHandler: errorHandler,
+		listeners:    listenerMap{},
+		handlers:     map[string]handler{},
+	}
 }
 
 func (w *Window) createWebView() {
@@ -99,30 +103,44 @@ func (w *Window) createWebView() {
 		Width:     800,
 		Height:    600,
 		ExternalInvokeCallback: func(view webview.WebView, data string) {
-			var obj payload
-			var err = json.Unmarshal([]byte(data), &obj)
-			if err != nil {
-				w.invokeError(fmt.Errorf("externalInvokeCallback: json: unmarshal: %s", err))
-				return
-			}
-			w.invoke(obj.Method, obj.Payload)
+			w.receive(data)
 		},
 	})
-	w.view = view
+	w.host = webviewHost{view}
+}
+
+// receive handles a message sent by the page.
+func (w *Window) receive(data string) {
+	var obj payload
+	var err = json.Unmarshal([]byte(data), &obj)
+	if err != nil {
+		w.invokeError(fmt.Errorf("receive: json: unmarshal: %s", err))
+		return
+	}
+	if obj.ID != 0 {
+		w.call(obj.ID, obj.Method, obj.Args)
+		return
+	}
+	w.invoke(obj.Method, obj.Payload)
 }
 
 func (w *Window) invoke(method string, payload string) {
 	var handler func([]byte)
 	var ok bool
+	w.lock.Lock()
 	handler, ok = w.listeners[method]
+	w.lock.Unlock()
 	if !ok {
 		return
 	}
 	handler([]byte(payload))
 }
 
-// Register ?
+// Register makes handler receive the payload of every message the page
+// sends for event, replacing any previous handler of the event.
 func (w *Window) Register(event string, handler func([]byte)) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
 	w.listeners[event] = handler
 }
 
@@ -135,86 +153,263 @@ func (w *Window) invokeError(err error) {
 }
 
 func (w *Window) createHTTPServer() error {
-	var err error
 	var listener net.Listener
-	listener, err = net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		return err
-	}
-	var sfs http.FileSystem
-	sfs, err = fs.New()
+	var mux *http.ServeMux
+	var err error
+	listener, mux, err = newHTTPServer("127.0.0.1:0")
 	if err != nil {
 		return err
 	}
 	w.server.http = &http.Server{
-		Handler: http.StripPrefix("/public/", http.FileServer(sfs)),
+		Handler: mux,
 	}
 	w.server.listener = listener
 	return nil
 }
 
-// Eval ?
+// newHTTPServer listens on address and serves the embedded page under
+// /public/. Callers can add more handlers to the returned mux.
+func newHTTPServer(address string) (net.Listener, *http.ServeMux, error) {
+	var err error
+	var listener net.Listener
+	listener, err = net.Listen("tcp", address)
+	if err != nil {
+		return nil, nil, err
+	}
+	var sfs http.FileSystem
+	sfs, err = fs.New()
+	if err != nil {
+		listener.Close()
+		return nil, nil, err
+	}
+	var mux = http.NewServeMux()
+	mux.Handle("/public/", http.StripPrefix("/public/", http.FileServer(sfs)))
+	return listener, mux, nil
+}
+
+func (w *Window) currentHost() host {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.host
+}
+
+// Eval evaluates content in the page. It returns before the evaluation takes
+// place; evaluation errors go to the error handler.
 func (w *Window) Eval(content string) error {
-	log.Println(content)
-	return w.view.Eval(content)
+	var h = w.currentHost()
+	if h == nil {
+		return nil
+	}
+	h.eval(content, func(err error) {
+		w.invokeError(fmt.Errorf("window: eval: %s", err))
+	})
+	return nil
 }
 
-// Terminate ?
+// Terminate closes the window, or the connection to the browser showing it.
 func (w *Window) Terminate() {
-	if w.view != nil {
-		w.view.Terminate()
-		w.view = nil
+	w.lock.Lock()
+	var closed = w.host != nil
+	if w.host != nil {
+		w.host.terminate()
+		w.host = nil
 	}
 	if w.server.http != nil {
 		w.server.http.Close()
 		w.server.http = nil
 	}
+	var handlers = w.closeHandlers
+	w.lock.Unlock()
+	if !closed {
+		return
+	}
+	var handler func()
+	for _, handler = range handlers {
+		handler()
+	}
 }
 
-// SetTitle ?
+// OnClose adds a function called once the window is closed, or once the
+// browser showing it disconnects.
+func (w *Window) OnClose(handler func()) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.closeHandlers = append(w.closeHandlers, handler)
+}
+
+// SetTitle sets the title of the window, or of the page when it is shown in
+// a browser.
 func (w *Window) SetTitle(title string) {
-	w.view.SetTitle(title)
+	var h = w.currentHost()
+	if h != nil {
+		h.setTitle(title)
+	}
 }
 
-// Run ?
+// Run shows the webview window until it is closed. Windows of a Server are
+// run by the server instead.
 func (w *Window) Run() error {
+	var h, ok = w.currentHost().(webviewHost)
+	if !ok {
+		return fmt.Errorf("window: run: not a webview window")
+	}
 	var errchan = make(chan error)
 	go func() {
 		errchan <- w.server.http.Serve(w.server.listener)
 	}()
-	w.view.Run()
+	h.view.Run()
 	w.Terminate()
 	return nil
 }
 
-// AddNode ?
-func (w *Window) AddNode(id int, label string, kind NodeKind) {
-	var obj = node{label, id, kind}
-	w.nodes = append(w.nodes, obj)
+// AddNode adds a node to the graph, or replaces the node with the same id.
+// IDs are strings so that callers can use stable identifiers, such as the
+// IDs of dependency.Entity, that survive reloads.
+func (w *Window) AddNode(id string, label string, style NodeStyle) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.addNode(w.newNode(id, label, style))
+}
+
+// UpdateNode replaces the label and style of an existing node.
+func (w *Window) UpdateNode(id string, label string, style NodeStyle) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	var ok bool
+	_, ok = w.nodes[id]
+	if !ok {
+		return
+	}
+	w.addNode(w.newNode(id, label, style))
+}
+
+// RemoveNode removes a node and every edge attached to it.
+func (w *Window) RemoveNode(id string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.removeNode(id)
+}
+
+// AddEdge adds an edge between two nodes.
+func (w *Window) AddEdge(from, to string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	var obj = edge{edgeID(from, to), from, to}
+	var ok bool
+	_, ok = w.edges[obj.ID]
+	if ok {
+		return
+	}
+	w.edges[obj.ID] = obj
+	w.changes.updateEdge(obj)
+}
+
+// RemoveEdge removes the edge between two nodes.
+func (w *Window) RemoveEdge(from, to string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.removeEdge(edgeID(from, to))
+}
+
+// Clear removes every node and edge.
+func (w *Window) Clear() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	var id string
+	for id = range w.nodes {
+		w.removeNode(id)
+	}
 }
 
-// AddEdge ?
-func (w *Window) AddEdge(from, to int) {
-	var obj = edge{from, to}
-	w.edges = append(w.edges, obj)
+// Reset forgets every node and edge without removing them from the page. It
+// is meant for when the page was reloaded and starts empty.
+func (w *Window) Reset() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.nodes = map[string]node{}
+	w.edges = map[string]edge{}
+	w.changes = newChangeSet()
+	w.icons = map[string]string{}
 }
 
-// Refresh ?
+// Refresh sends the changes made since the last refresh to the page, where
+// they are applied to the existing DataSets so that the viewport and the
+// physics state are kept.
 func (w *Window) Refresh() error {
-	var err error
-	var nodeString []byte
-	nodeString, err = json.Marshal(&w.nodes)
-	if err != nil {
-		return fmt.Errorf("window: refresh: json: marshal: nodes: %s", err)
+	w.lock.Lock()
+	if w.changes.empty() {
+		w.lock.Unlock()
+		return nil
 	}
-	var edgeString []byte
-	edgeString, err = json.Marshal(&w.edges)
+	var content []byte
+	var err error
+	content, err = json.Marshal(&w.changes)
+	w.changes = newChangeSet()
+	w.lock.Unlock()
 	if err != nil {
-		return fmt.Errorf("window: refresh: json: marshal: edges: %s", err)
+		return fmt.Errorf("window: refresh: json: marshal: %s", err)
 	}
-	err = w.Eval(fmt.Sprintf(`network.setData({nodes:new vis.DataSet(%s), edges:new vis.DataSet(%s)})`, string(nodeString), string(edgeString)))
+	err = w.Eval(fmt.Sprintf("applyChanges(%s)", string(content)))
 	if err != nil {
 		return fmt.Errorf("window: refresh: eval: %s", err)
 	}
 	return nil
 }
+
+// newNode returns the node drawn with style, queueing its image to be sent
+// to the page if it was not sent yet.
+func (w *Window) newNode(id string, label string, style NodeStyle) node {
+	var result = node{Label: label, ID: id, Shape: style.Shape, Title: style.Title}
+	if style.Image == "" {
+		return result
+	}
+	var key, ok = w.icons[style.Image]
+	if !ok {
+		key = fmt.Sprintf("i%d", len(w.icons))
+		w.icons[style.Image] = key
+		w.changes.Icons[key] = style.Image
+	}
+	result.Icon = key
+	return result
+}
+
+func (w *Window) addNode(obj node) {
+	var current node
+	var ok bool
+	current, ok = w.nodes[obj.ID]
+	if ok && current == obj {
+		return
+	}
+	w.nodes[obj.ID] = obj
+	w.changes.updateNode(obj)
+}
+
+func (w *Window) removeNode(id string) {
+	var ok bool
+	_, ok = w.nodes[id]
+	if !ok {
+		return
+	}
+	delete(w.nodes, id)
+	w.changes.removeNode(id)
+	var obj edge
+	for _, obj = range w.edges {
+		if obj.From == id || obj.To == id {
+			w.removeEdge(obj.ID)
+		}
+	}
+}
+
+func (w *Window) removeEdge(id string) {
+	var ok bool
+	_, ok = w.edges[id]
+	if !ok {
+		return
+	}
+	delete(w.edges, id)
+	w.changes.removeEdge(id)
+}
+
+func edgeID(from, to string) string {
+	return from + "->" + to
+}